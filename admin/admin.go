@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientStateResponse is the JSON representation of a client's limiter state.
+type ClientStateResponse struct {
+	ClientId     string     `json:"clientId"`
+	Tracked      bool       `json:"tracked"`
+	Limit        float64    `json:"limit"`
+	Remaining    float64    `json:"remaining"`
+	Tokens       float64    `json:"tokens,omitempty"`
+	Count        int        `json:"count,omitempty"`
+	Queued       float64    `json:"queued,omitempty"`
+	WindowStart  *time.Time `json:"windowStart,omitempty"`
+	RetryAfterMs int64      `json:"retryAfterMs"`
+}
+
+// New creates a Fiber app exposing the state of the given strategy.
+//
+// Parameters:
+//   - strategy: the strategy to manage. Listing and inspecting require it to
+//     implement strategies.Inspector; resetting and charging require
+//     strategies.Resetter. Unsupported operations respond with HTTP 501.
+//
+// Returns:
+//   - *fiber.App: an app meant to be mounted, e.g. app.Mount("/admin/ratelimit", admin.New(strategy)).
+//
+// Routes:
+//   - GET    /                   lists tracked client ids.
+//   - GET    /:clientId          shows the client's current state.
+//   - DELETE /:clientId          resets the client.
+//   - POST   /:clientId/charge   consumes ?n= units (default 1) of the client's allowance.
+//
+// The app performs no authentication; protect the mount point with your own middleware.
+func New(strategy strategies.RateLimitStrategy) *fiber.App {
+	app := fiber.New()
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		inspector, ok := strategy.(strategies.Inspector)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotImplemented, "strategy does not support inspection")
+		}
+		return c.JSON(fiber.Map{"clients": inspector.Clients()})
+	})
+
+	app.Get("/:clientId", func(c *fiber.Ctx) error {
+		inspector, ok := strategy.(strategies.Inspector)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotImplemented, "strategy does not support inspection")
+		}
+		clientId, err := clientIdParam(c)
+		if err != nil {
+			return err
+		}
+		return c.JSON(toResponse(inspector.Inspect(clientId)))
+	})
+
+	app.Delete("/:clientId", func(c *fiber.Ctx) error {
+		resetter, ok := strategy.(strategies.Resetter)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotImplemented, "strategy does not support resetting")
+		}
+		clientId, err := clientIdParam(c)
+		if err != nil {
+			return err
+		}
+		resetter.Reset(clientId)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	app.Post("/:clientId/charge", func(c *fiber.Ctx) error {
+		resetter, ok := strategy.(strategies.Resetter)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotImplemented, "strategy does not support charging")
+		}
+		clientId, err := clientIdParam(c)
+		if err != nil {
+			return err
+		}
+		n := 1
+		if raw := c.Query("n"); raw != "" {
+			n, err = strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				return fiber.NewError(fiber.StatusBadRequest, "n must be a positive integer")
+			}
+		}
+		resetter.Charge(clientId, n)
+
+		if inspector, ok := strategy.(strategies.Inspector); ok {
+			return c.JSON(toResponse(inspector.Inspect(clientId)))
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	return app
+}
+
+// clientIdParam returns the unescaped :clientId route parameter so ids
+// containing reserved characters (e.g. IPv6 addresses) can be addressed.
+func clientIdParam(c *fiber.Ctx) (string, error) {
+	clientId, err := url.PathUnescape(c.Params("clientId"))
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid client id")
+	}
+	return clientId, nil
+}
+
+func toResponse(state strategies.ClientState, tracked bool) ClientStateResponse {
+	response := ClientStateResponse{
+		ClientId:     state.ClientId,
+		Tracked:      tracked,
+		Limit:        state.Limit,
+		Remaining:    state.Remaining,
+		Tokens:       state.Tokens,
+		Count:        state.Count,
+		Queued:       state.Queued,
+		RetryAfterMs: state.RetryAfter.Milliseconds(),
+	}
+	if !state.WindowStart.IsZero() {
+		response.WindowStart = &state.WindowStart
+	}
+	return response
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+// onlyStrategy hides the optional interfaces of the wrapped strategy.
+type onlyStrategy struct {
+	strategies.RateLimitStrategy
+}
+
+func newMountedApp(strategy strategies.RateLimitStrategy) *fiber.App {
+	app := fiber.New()
+	app.Mount("/admin/ratelimit", New(strategy))
+	return app
+}
+
+func doRequest(t *testing.T, app *fiber.App, method, target string, out any) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, target, nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAdminListsAndInspectsClients(t *testing.T) {
+	s := strategies.NewFixedWindowStrategy(2, time.Minute)
+	_ = s.IsRequestAllowed("userB")
+	_ = s.IsRequestAllowed("userA")
+	_ = s.IsRequestAllowed("userA")
+	app := newMountedApp(s)
+
+	var list struct {
+		Clients []string `json:"clients"`
+	}
+	if status := doRequest(t, app, http.MethodGet, "/admin/ratelimit", &list); status != fiber.StatusOK {
+		t.Fatalf("list: expected 200, got %d", status)
+	}
+	if len(list.Clients) != 2 || list.Clients[0] != "userA" || list.Clients[1] != "userB" {
+		t.Fatalf("unexpected client list %v", list.Clients)
+	}
+
+	var state ClientStateResponse
+	if status := doRequest(t, app, http.MethodGet, "/admin/ratelimit/userA", &state); status != fiber.StatusOK {
+		t.Fatalf("inspect: expected 200, got %d", status)
+	}
+	if !state.Tracked || state.Count != 2 || state.Remaining != 0 || state.RetryAfterMs <= 0 {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
+
+func TestAdminResetRestoresAllowance(t *testing.T) {
+	s := strategies.NewTokenBucketStrategy(0, 1)
+	_ = s.IsRequestAllowed("userA")
+	app := newMountedApp(s)
+
+	if status := doRequest(t, app, http.MethodDelete, "/admin/ratelimit/userA", nil); status != fiber.StatusNoContent {
+		t.Fatalf("reset: expected 204, got %d", status)
+	}
+	if !s.IsRequestAllowed("userA") {
+		t.Fatal("expected request allowed after reset")
+	}
+}
+
+func TestAdminChargeConsumesAllowance(t *testing.T) {
+	s := strategies.NewLeakyBucketStrategy(0, 3)
+	app := newMountedApp(s)
+
+	var state ClientStateResponse
+	if status := doRequest(t, app, http.MethodPost, "/admin/ratelimit/userA/charge?n=3", &state); status != fiber.StatusOK {
+		t.Fatalf("charge: expected 200, got %d", status)
+	}
+	if state.Queued != 3 || state.Remaining != 0 {
+		t.Fatalf("unexpected state after charge %+v", state)
+	}
+	if s.IsRequestAllowed("userA") {
+		t.Fatal("expected request denied after pre-charge")
+	}
+
+	if status := doRequest(t, app, http.MethodPost, "/admin/ratelimit/userA/charge?n=abc", nil); status != fiber.StatusBadRequest {
+		t.Fatalf("invalid n: expected 400, got %d", status)
+	}
+}
+
+func TestAdminUnescapesClientId(t *testing.T) {
+	s := strategies.NewSlidingWindowStrategy(1, time.Minute)
+	_ = s.IsRequestAllowed("2001:db8::1/key")
+	app := newMountedApp(s)
+
+	var state ClientStateResponse
+	doRequest(t, app, http.MethodGet, "/admin/ratelimit/2001:db8::1%2Fkey", &state)
+	if !state.Tracked || state.ClientId != "2001:db8::1/key" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
+
+func TestAdminReportsUnsupportedOperations(t *testing.T) {
+	app := newMountedApp(onlyStrategy{strategies.NewFixedWindowStrategy(1, time.Minute)})
+
+	if status := doRequest(t, app, http.MethodGet, "/admin/ratelimit", nil); status != fiber.StatusNotImplemented {
+		t.Fatalf("list: expected 501, got %d", status)
+	}
+	if status := doRequest(t, app, http.MethodDelete, "/admin/ratelimit/userA", nil); status != fiber.StatusNotImplemented {
+		t.Fatalf("reset: expected 501, got %d", status)
+	}
+}
@@ -0,0 +1,163 @@
+// Command loadtest fires a configurable traffic pattern at an in-process Fiber
+// app protected by RateLimitingMiddleware and reports allowed and denied rates
+// along with the latency the middleware adds over an unprotected app.
+//
+// Usage:
+//
+//	go run ./cmd/loadtest -strategy token-bucket -rate 50 -burst 100 -pattern zipf -requests 200000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/internal/strategyflags"
+	"github.com/gabisonia/fiber-rate-limiter/middleware"
+	"github.com/gabisonia/fiber-rate-limiter/resolvers"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const clientHeader = "X-Client-Id"
+
+func main() {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	strategyFlags := strategyflags.Register(fs)
+	requests := fs.Int("requests", 100000, "total number of requests")
+	concurrency := fs.Int("concurrency", 8, "number of concurrent workers")
+	clients := fs.Int("clients", 100, "number of distinct clients")
+	pattern := fs.String("pattern", "uniform", "client distribution: uniform, zipf (a few hot clients) or single")
+	_ = fs.Parse(os.Args[1:])
+
+	if err := strategyflags.RequirePositive(map[string]float64{
+		"requests":    float64(*requests),
+		"concurrency": float64(*concurrency),
+		"clients":     float64(*clients),
+	}); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	strategy, err := strategyFlags.New(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pick, err := picker(*pattern, *clients)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("strategy:    %s\n", strategyFlags)
+	fmt.Printf("traffic:     %d requests, %d workers, %d clients, %s\n\n", *requests, *concurrency, *clients, *pattern)
+
+	baseline := run(newApp(nil), *requests, *concurrency, pick)
+	limited := run(newApp(strategy), *requests, *concurrency, pick)
+
+	total := float64(*requests)
+	fmt.Printf("allowed:     %d (%.1f%%)\n", limited.allowed, 100*float64(limited.allowed)/total)
+	fmt.Printf("denied:      %d (%.1f%%)\n", limited.denied, 100*float64(limited.denied)/total)
+	fmt.Printf("throughput:  %.0f req/s (baseline %.0f req/s)\n", total/limited.elapsed.Seconds(), total/baseline.elapsed.Seconds())
+	fmt.Printf("p50:         %v (baseline %v)\n", limited.percentile(0.50), baseline.percentile(0.50))
+	fmt.Printf("p99:         %v (baseline %v)\n", limited.percentile(0.99), baseline.percentile(0.99))
+	fmt.Printf("p99 overhead: %v\n", limited.percentile(0.99)-baseline.percentile(0.99))
+}
+
+// newApp returns the handler under test, rate limited unless strategy is nil.
+func newApp(strategy strategies.RateLimitStrategy) fasthttp.RequestHandler {
+	app := fiber.New()
+	if strategy != nil {
+		app.Use(middleware.RateLimitingMiddleware(strategy, resolvers.Header(clientHeader)))
+	}
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app.Handler()
+}
+
+// picker returns a constructor for per-worker functions choosing the client
+// of the next request.
+func picker(pattern string, clients int) (func(*rand.Rand) func() int, error) {
+	switch pattern {
+	case "uniform":
+		return func(rng *rand.Rand) func() int {
+			return func() int { return rng.Intn(clients) }
+		}, nil
+	case "zipf":
+		return func(rng *rand.Rand) func() int {
+			zipf := rand.NewZipf(rng, 1.2, 1, uint64(max(1, clients-1)))
+			return func() int { return int(zipf.Uint64()) }
+		}, nil
+	case "single":
+		return func(*rand.Rand) func() int {
+			return func() int { return 0 }
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown pattern %q", pattern)
+	}
+}
+
+type result struct {
+	allowed, denied int64
+	latencies       []time.Duration
+	elapsed         time.Duration
+}
+
+func (r result) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	return r.latencies[int(p*float64(len(r.latencies)-1))]
+}
+
+// run sends requests through handler from concurrent workers.
+func run(handler fasthttp.RequestHandler, requests, concurrency int, pick func(*rand.Rand) func() int) result {
+	var allowed, denied, next atomic.Int64
+	latencies := make([]time.Duration, requests)
+	remote := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			nextClient := pick(rand.New(rand.NewSource(seed)))
+			var ctx fasthttp.RequestCtx
+			var req fasthttp.Request
+
+			for {
+				i := next.Add(1) - 1
+				if i >= int64(requests) {
+					return
+				}
+				req.Reset()
+				req.SetRequestURI("/")
+				req.Header.Set(clientHeader, strconv.Itoa(nextClient()))
+				ctx.Init(&req, remote, nil)
+
+				began := time.Now()
+				handler(&ctx)
+				latencies[i] = time.Since(began)
+
+				if ctx.Response.StatusCode() == fiber.StatusTooManyRequests {
+					denied.Add(1)
+				} else {
+					allowed.Add(1)
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return result{allowed: allowed.Load(), denied: denied.Load(), latencies: latencies, elapsed: time.Since(start)}
+}
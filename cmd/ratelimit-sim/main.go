@@ -0,0 +1,105 @@
+// Command ratelimit-sim previews a rate limiting policy offline. It replays a
+// recorded request log or synthetic traffic against a strategy on a virtual
+// clock and prints per-client allowed and denied counts and timelines.
+//
+// Usage:
+//
+//	go run ./cmd/ratelimit-sim -strategy token-bucket -rate 5 -burst 10 -traffic poisson -rps 40 -clients 4 -duration 1m
+//	go run ./cmd/ratelimit-sim -strategy sliding-window -limit 100 -window 1m -log requests.log
+//	go run ./cmd/ratelimit-sim -log access.log -format combined -key header:X-API-Key,ip
+//
+// With the default "simple" format, log files contain one
+// "<RFC 3339 timestamp> <client>" line per request. The common, combined and
+// jsonl formats are parsed by the replay package, and -key selects how their
+// requests map to clients: a comma-separated list of ip, header:<name> and
+// jwt:<claim>, tried in order.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/internal/strategyflags"
+	"github.com/gabisonia/fiber-rate-limiter/replay"
+)
+
+func main() {
+	fs := flag.NewFlagSet("ratelimit-sim", flag.ExitOnError)
+	strategyFlags := strategyflags.Register(fs)
+	logPath := fs.String("log", "", "request log to replay instead of synthetic traffic")
+	format := fs.String("format", "simple", "log format: simple, common, combined or jsonl")
+	key := fs.String("key", "ip", "client key for common, combined and jsonl logs, e.g. header:X-API-Key,ip")
+	traffic := fs.String("traffic", "poisson", "synthetic traffic: poisson or burst")
+	duration := fs.Duration("duration", time.Minute, "length of synthetic traffic")
+	clients := fs.Int("clients", 5, "number of synthetic clients")
+	rps := fs.Float64("rps", 20, "total requests per second (poisson)")
+	burstSize := fs.Int("burst-size", 30, "requests per burst (burst)")
+	burstEvery := fs.Duration("burst-every", 10*time.Second, "time between bursts of a client (burst)")
+	interval := fs.Duration("interval", time.Second, "timeline resolution")
+	seed := fs.Int64("seed", 1, "random seed for synthetic traffic")
+	_ = fs.Parse(os.Args[1:])
+
+	if err := strategyflags.RequirePositive(map[string]float64{
+		"duration":    duration.Seconds(),
+		"clients":     float64(*clients),
+		"rps":         *rps,
+		"burst-size":  float64(*burstSize),
+		"burst-every": burstEvery.Seconds(),
+		"interval":    interval.Seconds(),
+	}); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	events, err := loadEvents(*logPath, *format, *key, *traffic, *seed, *duration, *clients, *rps, *burstSize, *burstEvery)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reports, err := simulate(strategyFlags.New, events, *interval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("strategy: %s\n", strategyFlags)
+	fmt.Printf("requests: %d, timeline: one column per %s (+ allowed, x denied, ~ mixed)\n\n", len(events), *interval)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT\tALLOWED\tDENIED\tDENIED%\tTIMELINE")
+	for _, report := range reports {
+		total := report.Allowed + report.Denied
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t|%s|\n", report.Client, report.Allowed, report.Denied, 100*float64(report.Denied)/float64(total), timeline(report.Timeline))
+	}
+	w.Flush()
+}
+
+func loadEvents(logPath, format, key, traffic string, seed int64, duration time.Duration, clients int, rps float64, burstSize int, burstEvery time.Duration) ([]Event, error) {
+	if logPath != "" {
+		file, err := os.Open(logPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if format == "simple" {
+			return readEvents(file)
+		}
+		return readRequests(file, replay.Format(format), key)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	start := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	switch traffic {
+	case "poisson":
+		return poisson(rng, start, duration, clients, rps), nil
+	case "burst":
+		return bursts(rng, start, duration, clients, burstSize, burstEvery), nil
+	default:
+		return nil, fmt.Errorf("unknown traffic %q", traffic)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+)
+
+// Event is a single request to replay.
+type Event struct {
+	Time   time.Time
+	Client string
+}
+
+// ClientReport summarizes the decisions for one client.
+type ClientReport struct {
+	Client  string
+	Allowed int
+	Denied  int
+	// Timeline holds the allowed and denied counts of each interval.
+	Timeline []Interval
+}
+
+// Interval counts decisions within one timeline slot.
+type Interval struct {
+	Allowed int
+	Denied  int
+}
+
+// simulate replays events, which must be sorted by time, against a strategy
+// driven by a virtual clock and groups the decisions by client and interval.
+func simulate(newStrategy func(strategies.Clock) (strategies.RateLimitStrategy, error), events []Event, interval time.Duration) ([]ClientReport, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	start := events[0].Time
+	clock := strategytest.NewFakeClock(start)
+	strategy, err := newStrategy(clock)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := int(events[len(events)-1].Time.Sub(start)/interval) + 1
+	reports := make(map[string]*ClientReport)
+	for _, event := range events {
+		clock.Set(event.Time)
+
+		report, exists := reports[event.Client]
+		if !exists {
+			report = &ClientReport{Client: event.Client, Timeline: make([]Interval, slots)}
+			reports[event.Client] = report
+		}
+
+		slot := &report.Timeline[int(event.Time.Sub(start)/interval)]
+		if strategy.IsRequestAllowed(event.Client) {
+			report.Allowed++
+			slot.Allowed++
+		} else {
+			report.Denied++
+			slot.Denied++
+		}
+	}
+
+	result := make([]ClientReport, 0, len(reports))
+	for _, report := range reports {
+		result = append(result, *report)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Client < result[j].Client })
+	return result, nil
+}
+
+// timeline renders one character per interval: ' ' idle, '+' all allowed,
+// 'x' all denied and '~' mixed.
+func timeline(intervals []Interval) string {
+	out := make([]byte, len(intervals))
+	for i, slot := range intervals {
+		switch {
+		case slot.Allowed == 0 && slot.Denied == 0:
+			out[i] = ' '
+		case slot.Denied == 0:
+			out[i] = '+'
+		case slot.Allowed == 0:
+			out[i] = 'x'
+		default:
+			out[i] = '~'
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+func TestSimulateUsesVirtualClock(t *testing.T) {
+	events, err := readEvents(strings.NewReader(`
+# two requests per client, ten seconds apart
+2030-01-01T00:00:00Z alice
+2030-01-01T00:00:00Z alice
+2030-01-01T00:00:10Z alice
+2030-01-01T00:00:05Z bob
+`))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+
+	newStrategy := func(clock strategies.Clock) (strategies.RateLimitStrategy, error) {
+		s := strategies.NewFixedWindowStrategy(1, 10*time.Second)
+		s.Clock = clock
+		return s, nil
+	}
+	reports, err := simulate(newStrategy, events, 5*time.Second)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+
+	if len(reports) != 2 || reports[0].Client != "alice" || reports[1].Client != "bob" {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+	// the second request is denied; the third falls into a new window 10s later
+	if reports[0].Allowed != 2 || reports[0].Denied != 1 {
+		t.Fatalf("alice: expected 2 allowed and 1 denied, got %+v", reports[0])
+	}
+	if got := timeline(reports[0].Timeline); got != "~ +" {
+		t.Fatalf("alice: unexpected timeline %q", got)
+	}
+	if got := timeline(reports[1].Timeline); got != " + " {
+		t.Fatalf("bob: unexpected timeline %q", got)
+	}
+}
+
+func TestReadEventsRejectsMalformedLines(t *testing.T) {
+	if _, err := readEvents(strings.NewReader("yesterday alice\n")); err == nil {
+		t.Fatal("expected error for invalid timestamp")
+	}
+}
+
+func TestReadRequestsResolvesKeys(t *testing.T) {
+	log := `{"time":"2030-01-01T00:00:00Z","ip":"10.0.0.1","headers":{"X-API-Key":"k1"}}
+{"time":"2030-01-01T00:00:01Z","ip":"10.0.0.2"}`
+
+	events, err := readRequests(strings.NewReader(log), "jsonl", "header:X-API-Key,ip")
+	if err != nil {
+		t.Fatalf("read requests: %v", err)
+	}
+	if len(events) != 2 || events[0].Client != "k1" || events[1].Client != "10.0.0.2" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	if _, err := readRequests(strings.NewReader(log), "jsonl", "cookie:session"); err == nil {
+		t.Fatal("expected an invalid key to fail")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/replay"
+	"github.com/gabisonia/fiber-rate-limiter/resolvers"
+)
+
+// poisson generates requests from clients arriving independently at
+// rps/clients requests per second each.
+func poisson(rng *rand.Rand, start time.Time, duration time.Duration, clients int, rps float64) []Event {
+	var events []Event
+	perClient := rps / float64(clients)
+	for c := 0; c < clients; c++ {
+		client := "client-" + strconv.Itoa(c)
+		at := start
+		for {
+			at = at.Add(time.Duration(rng.ExpFloat64() / perClient * float64(time.Second)))
+			if at.Sub(start) >= duration {
+				break
+			}
+			events = append(events, Event{Time: at, Client: client})
+		}
+	}
+	sortEvents(events)
+	return events
+}
+
+// bursts generates size back-to-back requests per client every period, with
+// clients offset randomly within the period.
+func bursts(rng *rand.Rand, start time.Time, duration time.Duration, clients, size int, period time.Duration) []Event {
+	var events []Event
+	for c := 0; c < clients; c++ {
+		client := "client-" + strconv.Itoa(c)
+		offset := time.Duration(rng.Int63n(int64(period)))
+		for at := start.Add(offset); at.Sub(start) < duration; at = at.Add(period) {
+			for i := 0; i < size; i++ {
+				events = append(events, Event{Time: at.Add(time.Duration(i) * time.Millisecond), Client: client})
+			}
+		}
+	}
+	sortEvents(events)
+	return events
+}
+
+// readEvents reads "<RFC 3339 timestamp> <client>" lines. Blank lines and
+// lines starting with # are skipped.
+func readEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		timestamp, client, found := strings.Cut(text, " ")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected \"<timestamp> <client>\"", line)
+		}
+		at, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, Event{Time: at, Client: strings.TrimSpace(client)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// readRequests parses an access log and keys its requests with the resolvers
+// described by spec.
+func readRequests(r io.Reader, format replay.Format, spec string) ([]Event, error) {
+	resolver, err := parseKey(spec)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := replay.Parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := replay.Keys(requests, resolver)
+	events := make([]Event, len(requests))
+	for i, request := range requests {
+		events[i] = Event{Time: request.Time, Client: keys[i]}
+	}
+	return events, nil
+}
+
+// parseKey builds a resolver from a comma-separated list of ip, header:<name>
+// and jwt:<claim>, falling through to the next entry when one yields no key.
+func parseKey(spec string) (resolvers.Resolver, error) {
+	var candidates []resolvers.Resolver
+	for _, entry := range strings.Split(spec, ",") {
+		kind, arg, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		switch {
+		case kind == "ip" && arg == "":
+			candidates = append(candidates, resolvers.IP())
+		case kind == "header" && arg != "":
+			candidates = append(candidates, resolvers.Header(arg))
+		case kind == "jwt" && arg != "":
+			candidates = append(candidates, resolvers.JWTClaim(arg))
+		default:
+			return nil, fmt.Errorf("invalid key %q", entry)
+		}
+	}
+	return resolvers.FirstOf(candidates...), nil
+}
+
+func sortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/gabisonia/fiber-rate-limiter/middleware"
+	"github.com/gabisonia/fiber-rate-limiter/resolvers"
 	"github.com/gabisonia/fiber-rate-limiter/strategies"
 	"github.com/gofiber/fiber/v2"
 	"log"
@@ -14,6 +15,13 @@ func main() {
 	// Create a strategy (e.g., Token Bucket)
 	strategy := strategies.NewSlidingWindowStrategy(2, time.Minute)
 
+	// Resolve clients by API key, falling back to IP. Prefixes keep the two
+	// kinds of keys from colliding.
+	clientIdResolver := resolvers.FirstOf(
+		resolvers.Prefix("key", resolvers.Header("X-API-Key")),
+		resolvers.Prefix("ip", resolvers.IP()),
+	)
+
 	// Create middleware
 	limiter := middleware.RateLimitingMiddleware(strategy, clientIdResolver)
 
@@ -39,13 +47,3 @@ func main() {
 
 	log.Fatal(app.Listen(":3000"))
 }
-
-func clientIdResolver(c *fiber.Ctx) string {
-	apiKey := c.Get("X-API-Key")
-	if apiKey != "" {
-		return apiKey
-	}
-
-	// Fallback to just IP if API Key is missing
-	return c.IP()
-}
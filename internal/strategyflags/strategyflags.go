@@ -0,0 +1,90 @@
+// Package strategyflags builds strategies from command-line flags and
+// validates flag values for the tools under cmd/.
+package strategyflags
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+// Flags holds the strategy selection and its parameters.
+type Flags struct {
+	Strategy string
+	Limit    int
+	Window   time.Duration
+	Rate     float64
+	Burst    float64
+}
+
+// Register defines the strategy flags on fs.
+func Register(fs *flag.FlagSet) *Flags {
+	flags := &Flags{}
+	fs.StringVar(&flags.Strategy, "strategy", "token-bucket", "fixed-window, aligned-fixed-window, sliding-window, token-bucket, leaky-bucket or fair-share")
+	fs.IntVar(&flags.Limit, "limit", 10, "requests per window (window strategies)")
+	fs.DurationVar(&flags.Window, "window", time.Second, "window size (window strategies)")
+	fs.Float64Var(&flags.Rate, "rate", 10, "refill or leak rate per second (bucket strategies)")
+	fs.Float64Var(&flags.Burst, "burst", 20, "bucket size (bucket strategies)")
+	return flags
+}
+
+// New creates the selected strategy, driven by clock if it is not nil.
+func (flags *Flags) New(clock strategies.Clock) (strategies.RateLimitStrategy, error) {
+	switch flags.Strategy {
+	case "fixed-window":
+		s := strategies.NewFixedWindowStrategy(flags.Limit, flags.Window)
+		s.Clock = clock
+		return s, nil
+	case "aligned-fixed-window":
+		s := strategies.NewAlignedFixedWindowStrategy(flags.Limit, flags.Window)
+		s.Clock = clock
+		return s, nil
+	case "sliding-window":
+		s := strategies.NewSlidingWindowStrategy(flags.Limit, flags.Window)
+		s.Clock = clock
+		return s, nil
+	case "token-bucket":
+		s := strategies.NewTokenBucketStrategy(flags.Rate, flags.Burst)
+		s.Clock = clock
+		return s, nil
+	case "leaky-bucket":
+		s := strategies.NewLeakyBucketStrategy(flags.Rate, flags.Burst)
+		s.Clock = clock
+		return s, nil
+	case "fair-share":
+		s := strategies.NewFairShareStrategy(flags.Limit, flags.Window)
+		s.Clock = clock
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", flags.Strategy)
+	}
+}
+
+// String describes the selected strategy and its parameters.
+func (flags *Flags) String() string {
+	switch flags.Strategy {
+	case "token-bucket", "leaky-bucket":
+		return fmt.Sprintf("%s rate=%g/s burst=%g", flags.Strategy, flags.Rate, flags.Burst)
+	default:
+		return fmt.Sprintf("%s limit=%d window=%s", flags.Strategy, flags.Limit, flags.Window)
+	}
+}
+
+// RequirePositive returns an error naming the first flag, in sorted order,
+// whose value is not positive.
+func RequirePositive(values map[string]float64) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if values[name] <= 0 {
+			return fmt.Errorf("invalid value for flag -%s: must be positive", name)
+		}
+	}
+	return nil
+}
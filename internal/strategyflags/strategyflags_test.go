@@ -0,0 +1,16 @@
+package strategyflags
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequirePositiveRejectsZeroFlags(t *testing.T) {
+	err := RequirePositive(map[string]float64{"rps": 20, "interval": 0, "burst-every": -1})
+	if err == nil || !strings.Contains(err.Error(), "-burst-every") {
+		t.Fatalf("expected the first non-positive flag to be reported, got %v", err)
+	}
+	if err := RequirePositive(map[string]float64{"rps": 0.5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
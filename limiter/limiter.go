@@ -0,0 +1,176 @@
+// Package limiter holds the framework-independent steps the middleware
+// adapters share: evaluating a per-client and an optional global strategy,
+// recording what an admitted request was charged so it can be refunded once,
+// reporting to strategies.Observer, and the Retry-After conventions. The Fiber
+// v2 and v3 middleware are thin wrappers around it; use it to write adapters
+// for other frameworks.
+package limiter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+// GlobalKey is the key under which the global strategy is evaluated.
+const GlobalKey = "global"
+
+// Limiter applies a per-client strategy and an optional global one.
+type Limiter struct {
+	Strategy strategies.RateLimitStrategy
+	// Global, if set, is evaluated under GlobalKey after Strategy admits a
+	// request. If it rejects, the per-client unit is refunded, so Strategy
+	// must implement strategies.Refunder.
+	Global strategies.RateLimitStrategy
+	// SkipFailedRequests refunds requests whose status is >= 400.
+	SkipFailedRequests bool
+	// SkipSuccessfulRequests refunds requests whose status is < 400.
+	SkipSuccessfulRequests bool
+}
+
+// Validate returns an error if the settings require a capability, such as
+// refunds, that the strategies do not implement.
+func (l *Limiter) Validate() error {
+	if l.SkipFailedRequests || l.SkipSuccessfulRequests {
+		if _, ok := l.Strategy.(strategies.Refunder); !ok {
+			return fmt.Errorf("skipping requests requires a strategies.Refunder, got %T", l.Strategy)
+		}
+		if _, ok := l.Global.(strategies.Refunder); l.Global != nil && !ok {
+			return fmt.Errorf("skipping requests requires a strategies.Refunder, got global %T", l.Global)
+		}
+	}
+	if _, ok := l.Strategy.(strategies.Refunder); l.Global != nil && !ok {
+		return fmt.Errorf("a global strategy requires a per-client strategies.Refunder, got %T", l.Strategy)
+	}
+	return nil
+}
+
+// Allow evaluates the request for key. It returns the admission if every
+// strategy admitted the request, or else the denial of the one that rejected
+// it; a request the global strategy rejects is not charged to key.
+func (l *Limiter) Allow(key string) (*Admission, *Denial) {
+	if !l.Strategy.IsRequestAllowed(key) {
+		return nil, &Denial{Strategy: l.Strategy, Key: key}
+	}
+	if l.Global != nil && !l.Global.IsRequestAllowed(GlobalKey) {
+		l.Strategy.(strategies.Refunder).Refund(key, 1)
+		return nil, &Denial{Strategy: l.Global, Key: GlobalKey}
+	}
+
+	admission := &Admission{limiter: l}
+	admission.charge(l.Strategy, key)
+	if l.Global != nil {
+		admission.charge(l.Global, GlobalKey)
+	}
+	return admission, nil
+}
+
+// Denial identifies the strategy that rejected a request.
+type Denial struct {
+	Strategy strategies.RateLimitStrategy
+	// Key is the key the request was evaluated under.
+	Key string
+}
+
+// RetryAfter returns how long until the request may be retried.
+func (d *Denial) RetryAfter() time.Duration {
+	return d.Strategy.RetryAfter(d.Key)
+}
+
+// Limit returns the capacity of the rejecting strategy, or zero if it does
+// not implement strategies.Inspector.
+func (d *Denial) Limit() float64 {
+	if inspector, ok := d.Strategy.(strategies.Inspector); ok {
+		state, _ := inspector.Inspect(d.Key)
+		return state.Limit
+	}
+	return 0
+}
+
+// Admission records the units charged for an admitted request.
+type Admission struct {
+	limiter *Limiter
+	charges []*charge
+}
+
+// charge is a unit charged by one strategy, refunded at most once.
+type charge struct {
+	strategy strategies.RateLimitStrategy
+	key      string
+	charged  int
+	mutex    sync.Mutex
+}
+
+func (admission *Admission) charge(strategy strategies.RateLimitStrategy, key string) {
+	admission.charges = append(admission.charges, &charge{strategy: strategy, key: key, charged: 1})
+}
+
+// Refund gives back the units charged for the request, e.g. after a cache
+// hit. It returns true if at least one unit was refunded. Strategies that do
+// not implement strategies.Refunder are skipped, and a request is never
+// refunded more than it was charged.
+func (admission *Admission) Refund() bool {
+	refunded := false
+	for _, c := range admission.charges {
+		if c.refund() {
+			refunded = true
+		}
+	}
+	return refunded
+}
+
+// Finish reports the handler latency and response status to strategies
+// implementing strategies.Observer, then refunds the request if the skip
+// settings exclude it.
+func (admission *Admission) Finish(latency time.Duration, status int) {
+	for _, c := range admission.charges {
+		if observer, ok := c.strategy.(strategies.Observer); ok {
+			observer.Observe(latency, status)
+		}
+	}
+
+	failed := status >= 400
+	l := admission.limiter
+	if (failed && l.SkipFailedRequests) || (!failed && l.SkipSuccessfulRequests) {
+		admission.Refund()
+	}
+}
+
+func (c *charge) refund() bool {
+	refunder, ok := c.strategy.(strategies.Refunder)
+	if !ok {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.charged == 0 {
+		return false
+	}
+	c.charged--
+	refunder.Refund(c.key, 1)
+	return true
+}
+
+// RetryAfterSeconds rounds wait up to whole seconds to be conservative, as
+// Retry-After accepts only integers. Non-positive waits yield zero.
+func RetryAfterSeconds(wait time.Duration) int64 {
+	if wait <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(wait.Seconds()))
+}
+
+// RetryAfterHeader returns the Retry-After value for wait, or "" if the
+// request can be retried immediately and the header should be omitted.
+func RetryAfterHeader(wait time.Duration) string {
+	seconds := RetryAfterSeconds(wait)
+	if seconds == 0 {
+		return ""
+	}
+	return strconv.FormatInt(seconds, 10)
+}
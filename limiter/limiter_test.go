@@ -0,0 +1,82 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+func TestAllowRefundsClientWhenGlobalRejects(t *testing.T) {
+	perClient := strategies.NewTokenBucketStrategy(0, 5)
+	l := &Limiter{Strategy: perClient, Global: strategies.NewTokenBucketStrategy(0, 1)}
+
+	if admission, denial := l.Allow("a"); admission == nil || denial != nil {
+		t.Fatal("expected the first request to be admitted")
+	}
+	admission, denial := l.Allow("a")
+	if admission != nil || denial == nil || denial.Key != GlobalKey {
+		t.Fatalf("expected the global strategy to reject, got %+v", denial)
+	}
+	if denial.Limit() != 1 {
+		t.Fatalf("expected the global limit of 1, got %v", denial.Limit())
+	}
+	if state, _ := perClient.Inspect("a"); state.Tokens != 4 {
+		t.Fatalf("expected the rejected request not to be charged to the client, got %v tokens", state.Tokens)
+	}
+}
+
+func TestAdmissionRefundsOnce(t *testing.T) {
+	strategy := strategies.NewTokenBucketStrategy(0, 2)
+	l := &Limiter{Strategy: strategy, SkipFailedRequests: true}
+
+	admission, _ := l.Allow("a")
+	if !admission.Refund() || admission.Refund() {
+		t.Fatal("expected exactly one refund")
+	}
+	admission.Finish(time.Millisecond, 500)
+	if state, _ := strategy.Inspect("a"); state.Tokens != 2 {
+		t.Fatalf("expected skipping not to refund again, got %v tokens", state.Tokens)
+	}
+
+	admission, _ = l.Allow("a")
+	admission.Finish(time.Millisecond, 200)
+	if state, _ := strategy.Inspect("a"); state.Tokens != 1 {
+		t.Fatalf("expected a successful request to stay charged, got %v tokens", state.Tokens)
+	}
+}
+
+// noRefunds is a strategy that cannot give units back.
+type noRefunds struct{}
+
+func (noRefunds) IsRequestAllowed(string) bool    { return true }
+func (noRefunds) RetryAfter(string) time.Duration { return 0 }
+
+func TestValidate(t *testing.T) {
+	refunder := strategies.NewTokenBucketStrategy(1, 1)
+	cases := []struct {
+		name    string
+		limiter Limiter
+		valid   bool
+	}{
+		{"plain", Limiter{Strategy: noRefunds{}}, true},
+		{"global without refunds", Limiter{Strategy: noRefunds{}, Global: refunder}, false},
+		{"skip without refunds", Limiter{Strategy: noRefunds{}, SkipFailedRequests: true}, false},
+		{"skip with global without refunds", Limiter{Strategy: refunder, Global: noRefunds{}, SkipSuccessfulRequests: true}, false},
+		{"global with refunds", Limiter{Strategy: refunder, Global: noRefunds{}}, true},
+	}
+	for _, tc := range cases {
+		if err := tc.limiter.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tc.name, tc.valid, err)
+		}
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	cases := map[time.Duration]string{0: "", -time.Second: "", time.Millisecond: "1", 1500 * time.Millisecond: "2"}
+	for wait, want := range cases {
+		if got := RetryAfterHeader(wait); got != want {
+			t.Errorf("RetryAfterHeader(%s) = %q, want %q", wait, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// AccessList is a set of client keys and IP networks that can be swapped at
+// runtime. It is safe for concurrent use.
+type AccessList struct {
+	keys     map[string]struct{}
+	prefixes []netip.Prefix
+	mutex    sync.RWMutex
+}
+
+// NewAccessList creates an access list from the given entries.
+//
+// Parameters:
+//   - entries: client keys (e.g. API keys), IP addresses or CIDR ranges.
+//
+// Returns:
+//   - *AccessList: the populated list.
+//   - error: if an entry looks like a CIDR range but cannot be parsed.
+//
+// IP addresses and CIDR ranges match only the request IP; all other entries
+// match the resolved client id exactly.
+func NewAccessList(entries ...string) (*AccessList, error) {
+	list := &AccessList{}
+	if err := list.Replace(entries...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Replace atomically swaps the contents of the list. On error the list is
+// left unchanged.
+func (list *AccessList) Replace(entries ...string) error {
+	keys := make(map[string]struct{}, len(entries))
+	var prefixes []netip.Prefix
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Addresses and ranges only match the request IP, so a client cannot
+		// bypass the list by sending a listed address as its key.
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		if before, _, found := strings.Cut(entry, "/"); found {
+			if _, err := netip.ParseAddr(before); err == nil {
+				prefix, err := netip.ParsePrefix(entry)
+				if err != nil {
+					return fmt.Errorf("invalid CIDR %q: %w", entry, err)
+				}
+				if prefix.Addr().Is4In6() {
+					prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(0, prefix.Bits()-96))
+				}
+				prefixes = append(prefixes, prefix.Masked())
+				continue
+			}
+			// a key that happens to contain a slash
+		}
+		keys[entry] = struct{}{}
+	}
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	list.keys = keys
+	list.prefixes = prefixes
+	return nil
+}
+
+// Contains reports whether clientId matches a key or ip falls inside a listed
+// address or range.
+func (list *AccessList) Contains(clientId, ip string) bool {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if _, found := list.keys[clientId]; found {
+		return true
+	}
+	if len(list.prefixes) == 0 {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range list.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import "testing"
+
+func TestAccessListMatchesKeysAndNetworks(t *testing.T) {
+	list, err := NewAccessList("partner-key", "10.0.0.0/8", "192.168.1.7", "2001:db8::/32")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		clientId, ip string
+		want         bool
+	}{
+		{"partner-key", "203.0.113.1", true},
+		{"other", "10.20.30.40", true},
+		{"other", "::ffff:10.1.1.1", true},
+		{"other", "192.168.1.7", true},
+		{"other", "192.168.1.8", false},
+		{"other", "2001:db8:1::5", true},
+		{"other", "not-an-ip", false},
+		{"192.168.1.7", "203.0.113.1", false},
+		{"10.0.0.0/8", "203.0.113.1", false},
+	}
+	for _, tc := range cases {
+		if got := list.Contains(tc.clientId, tc.ip); got != tc.want {
+			t.Errorf("Contains(%q, %q) = %v, want %v", tc.clientId, tc.ip, got, tc.want)
+		}
+	}
+}
+
+func TestAccessListReplace(t *testing.T) {
+	list, _ := NewAccessList("a")
+	if err := list.Replace("b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Contains("a", "") || !list.Contains("b", "") {
+		t.Fatal("expected list contents to be swapped")
+	}
+
+	// a malformed CIDR must leave the previous contents untouched
+	if err := list.Replace("10.0.0.0/40"); err == nil {
+		t.Fatal("expected error for invalid CIDR")
+	}
+	if !list.Contains("b", "") {
+		t.Fatal("failed replace should keep previous contents")
+	}
+}
+
+func TestAccessListTreatsSlashedKeysAsKeys(t *testing.T) {
+	list, err := NewAccessList("team/service")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !list.Contains("team/service", "") {
+		t.Fatal("expected slashed key to match exactly")
+	}
+}
@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// BandwidthMiddleware creates a Fiber middleware that limits throughput in
+// bytes rather than requests.
+//
+// Parameters:
+//   - strategy: a strategy denominated in bytes, typically a
+//     TokenBucketStrategy whose refill rate is bytes per second.
+//   - clientIdResolver: function to extract a unique client ID from the request.
+//   - opts: WithRequestBodyCharge, WithResponseBodyCharge and WithResponsePacing
+//     select what is charged, defaulting to both bodies; access list and
+//     renderer options apply as in RateLimitingMiddleware.
+//
+// Returns:
+//   - fiber.Handler: the middleware function that checks bandwidth limits.
+//
+// A request body that does not fit the client's allowance is rejected with
+// HTTP 429 and a Retry-After for its size, or 413 if it exceeds the capacity
+// altogether. Response bodies are debited after the handler runs, or as they
+// are sent if they are streams of unknown length, which may leave the
+// allowance in debt; requests arriving before it is paid off are rejected. With pacing, responses are instead streamed as the allowance
+// refills, in chunks no larger than the capacity, and a stream that can never
+// be admitted fails with ErrPacingStalled. Responses from failed handlers are
+// not charged.
+func BandwidthMiddleware(strategy strategies.Weighted, clientIdResolver func(*fiber.Ctx) string, opts ...Option) fiber.Handler {
+	cfg := newConfig(opts)
+	if !cfg.chargeRequestBody && !cfg.chargeResponseBody && cfg.paceChunkSize <= 0 {
+		cfg.chargeRequestBody = true
+		cfg.chargeResponseBody = true
+	}
+	pacing := cfg.paceChunkSize > 0
+
+	return func(c *fiber.Ctx) error {
+		clientId := clientIdResolver(c)
+		if handled, err := cfg.screen(c, clientId); handled {
+			return err
+		}
+
+		if cfg.chargeRequestBody {
+			size := len(c.Body())
+			if !strategy.IsRequestAllowedN(clientId, size) {
+				return cfg.rejectBytes(c, strategy, clientId, size)
+			}
+		}
+		if cfg.chargeResponseBody && !pacing && strategy.RetryAfterN(clientId, 1) > 0 {
+			return cfg.rejectBytes(c, strategy, clientId, 1)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		response := c.Response()
+		switch {
+		case pacing:
+			source, size := detachBody(response)
+			response.SetBodyStream(&pacedReader{
+				source:   source,
+				strategy: strategy,
+				key:      clientId,
+				chunk:    chunkSize(strategy, clientId, cfg.paceChunkSize),
+				sleep:    cfg.sleep,
+			}, size)
+		case !cfg.chargeResponseBody:
+		case !response.IsBodyStream():
+			strategy.Debit(clientId, len(response.Body()))
+		case response.Header.ContentLength() >= 0:
+			strategy.Debit(clientId, response.Header.ContentLength())
+		default:
+			// The length of the stream is unknown, so debit it as it is sent.
+			source, size := detachBody(response)
+			response.SetBodyStream(&debitingReader{source: source, strategy: strategy, key: clientId}, size)
+		}
+		return nil
+	}
+}
+
+// rejectBytes renders the rejection for a request that needed size bytes.
+func (cfg *config) rejectBytes(c *fiber.Ctx, strategy strategies.Weighted, clientId string, size int) error {
+	rejection := cfg.rejection
+	rejection.ClientId = clientId
+	rejection.RetryAfter = strategy.RetryAfterN(clientId, size)
+	if inspector, ok := strategy.(strategies.Inspector); ok {
+		state, _ := inspector.Inspect(clientId)
+		rejection.Limit = state.Limit
+		if float64(size) > state.Limit {
+			rejection.Status = fiber.StatusRequestEntityTooLarge
+			rejection.Title = "Request Entity Too Large"
+			rejection.Detail = "Request body exceeds the bandwidth limit."
+			rejection.RetryAfter = 0
+		}
+	}
+	return cfg.render(c, rejection)
+}
+
+// chunkSize caps size at the strategy's capacity so every chunk can
+// eventually be admitted.
+func chunkSize(strategy strategies.Weighted, clientId string, size int) int {
+	if inspector, ok := strategy.(strategies.Inspector); ok {
+		state, _ := inspector.Inspect(clientId)
+		if state.Limit >= 1 {
+			size = min(size, int(state.Limit))
+		}
+	}
+	return size
+}
+
+// ErrPacingStalled is returned while streaming a paced response if the
+// strategy will never admit even a single byte, e.g. because it does not
+// refill.
+var ErrPacingStalled = errors.New("middleware: paced response can never be admitted")
+
+// pacedReader releases source in chunks as the strategy admits them.
+type pacedReader struct {
+	source   io.Reader
+	strategy strategies.Weighted
+	key      string
+	chunk    int
+	sleep    func(time.Duration)
+}
+
+func (r *pacedReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p[:min(len(p), r.chunk)])
+	if admitErr := r.admit(n); admitErr != nil {
+		return 0, admitErr
+	}
+	return n, err
+}
+
+// Close closes source if it is an io.Closer, as fasthttp would have.
+func (r *pacedReader) Close() error {
+	return closeSource(r.source)
+}
+
+// admit waits until the strategy has admitted n bytes. A chunk the strategy
+// will never admit at once, e.g. because it exceeds a capacity no Inspector
+// reported, is halved for this and later reads.
+func (r *pacedReader) admit(n int) error {
+	for n > 0 {
+		size := min(n, r.chunk)
+		if r.strategy.IsRequestAllowedN(r.key, size) {
+			n -= size
+			continue
+		}
+
+		wait := r.strategy.RetryAfterN(r.key, size)
+		switch {
+		case wait > 0:
+			r.sleep(wait)
+		case size > 1:
+			r.chunk = size / 2
+		default:
+			return ErrPacingStalled
+		}
+	}
+	return nil
+}
+
+// debitingReader debits the bytes of source as they are read.
+type debitingReader struct {
+	source   io.Reader
+	strategy strategies.Weighted
+	key      string
+}
+
+func (r *debitingReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p)
+	r.strategy.Debit(r.key, n)
+	return n, err
+}
+
+// Close closes source if it is an io.Closer, as fasthttp would have.
+func (r *debitingReader) Close() error {
+	return closeSource(r.source)
+}
+
+// detachBody takes the body out of the response without copying it and
+// returns it with its size, or -1 if unknown. A body stream is neither read
+// nor closed, so the caller can stream it and must close it.
+func detachBody(response *fasthttp.Response) (io.Reader, int) {
+	if stream := response.BodyStream(); stream != nil {
+		size := response.Header.ContentLength()
+		// Every fasthttp method that unsets a stream also closes it, e.g.
+		// returning a served file's reader to its pool, so rebuild the
+		// response around it instead.
+		var headers fasthttp.Response
+		response.CopyTo(&headers)
+		*response = fasthttp.Response{}
+		headers.CopyTo(response)
+		return stream, size
+	}
+
+	body := response.Body()
+	if buffered := response.SwapBody(nil); len(buffered) > 0 {
+		// The body lived in the response's pooled buffer, which the response
+		// no longer uses.
+		body = buffered
+	}
+	return bytes.NewReader(body), len(body)
+}
+
+func closeSource(source io.Reader) error {
+	if closer, ok := source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
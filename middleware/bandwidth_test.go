@@ -0,0 +1,317 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+	"github.com/gofiber/fiber/v2"
+)
+
+func post(t *testing.T, app *fiber.App, size int) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", size)))
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return resp
+}
+
+func TestBandwidthChargesRequestBodies(t *testing.T) {
+	strategy := strategies.NewTokenBucketStrategy(10, 100) // bytes per second, bytes
+	app := fiber.New()
+	app.Use(BandwidthMiddleware(strategy, func(*fiber.Ctx) string { return "client" }, WithRequestBodyCharge()))
+	app.Post("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	if resp := post(t, app, 60); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	resp := post(t, app, 60)
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "2" {
+		t.Fatalf("expected Retry-After=2 for the 20 missing bytes, got %q", got)
+	}
+	if resp := post(t, app, 200); resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a body over the capacity, got %d", resp.StatusCode)
+	}
+}
+
+func TestBandwidthChargesResponseBodies(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(100, 100) // bytes per second, bytes
+	strategy.Clock = clock
+	app := fiber.New()
+	app.Use(BandwidthMiddleware(strategy, func(*fiber.Ctx) string { return "client" }, WithResponseBodyCharge()))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(strings.Repeat("x", 1000)) })
+
+	expectStatus(t, app, "/", fiber.StatusOK)
+	expectStatus(t, app, "/", fiber.StatusTooManyRequests)
+	if state, _ := strategy.Inspect("client"); state.Tokens != -900 {
+		t.Fatalf("expected the 1000 byte response to leave a debt of 900 bytes, got %v tokens", state.Tokens)
+	}
+
+	// A capped charge would have refilled by now; the debt takes 9s to pay off.
+	clock.Advance(5 * time.Second)
+	expectStatus(t, app, "/", fiber.StatusTooManyRequests)
+	clock.Advance(5 * time.Second)
+	expectStatus(t, app, "/", fiber.StatusOK)
+}
+
+func TestBandwidthPacesResponses(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(1000, 10)
+	strategy.Clock = clock
+	body := bytes.Repeat([]byte("0123456789"), 10)
+
+	app := fiber.New()
+	app.Use(BandwidthMiddleware(strategy, func(*fiber.Ctx) string { return "client" },
+		WithResponsePacing(64),
+		func(cfg *config) { cfg.sleep = clock.Advance },
+	))
+	app.Get("/", func(c *fiber.Ctx) error { return c.Send(body) })
+
+	start := clock.Now()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || !bytes.Equal(got, body) {
+		t.Fatalf("expected the full body, got %d with %d bytes", resp.StatusCode, len(got))
+	}
+	// 10 bytes are available at once; the other 90 arrive at 1000 bytes/s.
+	if elapsed := clock.Now().Sub(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected the response to be paced over at least 90ms, took %s", elapsed)
+	}
+}
+
+// streamOnly hides bytes.Reader's io.WriterTo so the body is read in chunks.
+type streamOnly struct{ io.Reader }
+
+func TestBandwidthPacesStreamsInPlace(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(1000, 10)
+	strategy.Clock = clock
+	body := bytes.Repeat([]byte("0123456789"), 10)
+	source := &streamOnly{bytes.NewReader(body)}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if paced, ok := c.Response().BodyStream().(*pacedReader); !ok || paced.source != source {
+			t.Error("expected the handler's stream to be wrapped rather than buffered")
+		}
+		return err
+	})
+	app.Use(BandwidthMiddleware(strategy, func(*fiber.Ctx) string { return "client" },
+		WithResponsePacing(64),
+		func(cfg *config) { cfg.sleep = clock.Advance },
+	))
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Response().SetBodyStream(source, len(body))
+		return nil
+	})
+
+	start := clock.Now()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	if !bytes.Equal(got, body) {
+		t.Fatalf("expected the full body, got %d bytes", len(got))
+	}
+	if elapsed := clock.Now().Sub(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected the response to be paced over at least 90ms, took %s", elapsed)
+	}
+}
+
+// closableStream records how often it is closed.
+type closableStream struct {
+	io.Reader
+	closed int
+}
+
+func (stream *closableStream) Close() error {
+	stream.closed++
+	return nil
+}
+
+func TestBandwidthPacesClosableStreamsWithoutBuffering(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(1000, 10)
+	strategy.Clock = clock
+	body := bytes.Repeat([]byte("0123456789"), 10)
+	source := &closableStream{Reader: &streamOnly{bytes.NewReader(body)}}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if paced, ok := c.Response().BodyStream().(*pacedReader); !ok || paced.source != source {
+			t.Error("expected the handler's stream to be wrapped rather than buffered")
+		}
+		if source.closed != 0 {
+			t.Error("expected the stream to stay open until it is sent")
+		}
+		return err
+	})
+	app.Use(BandwidthMiddleware(strategy, func(*fiber.Ctx) string { return "client" },
+		WithResponsePacing(64),
+		func(cfg *config) { cfg.sleep = clock.Advance },
+	))
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Response().SetBodyStream(source, len(body))
+		return nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got, _ := io.ReadAll(resp.Body); !bytes.Equal(got, body) {
+		t.Fatalf("expected the full body, got %d bytes", len(got))
+	}
+	if source.closed != 1 {
+		t.Fatalf("expected the stream to be closed once, got %d", source.closed)
+	}
+}
+
+func TestBandwidthPacesServedFiles(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(100_000, 1024)
+	strategy.Clock = clock
+	body := bytes.Repeat([]byte("0123456789abcdef"), 512)
+	file := filepath.Join(t.TempDir(), "download.bin")
+	if err := os.WriteFile(file, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if paced, ok := c.Response().BodyStream().(*pacedReader); !ok {
+			t.Error("expected the file to be paced")
+		} else if _, buffered := paced.source.(*bytes.Reader); buffered {
+			t.Error("expected the file to be streamed rather than buffered")
+		}
+		return err
+	})
+	app.Use(BandwidthMiddleware(strategy, func(*fiber.Ctx) string { return "client" },
+		WithResponsePacing(512),
+		func(cfg *config) { cfg.sleep = clock.Advance },
+	))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendFile(file) })
+
+	start := clock.Now()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got, _ := io.ReadAll(resp.Body); !bytes.Equal(got, body) {
+		t.Fatalf("expected the full file, got %d bytes", len(got))
+	}
+	// 1 KiB is available at once; the other 7 KiB arrive at 100,000 bytes/s.
+	if elapsed := clock.Now().Sub(start); elapsed < 70*time.Millisecond {
+		t.Fatalf("expected the file to be paced over at least 70ms, took %s", elapsed)
+	}
+}
+
+func TestBandwidthDebitsStreamsWithoutBuffering(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(100, 100)
+	strategy.Clock = clock
+	body := bytes.Repeat([]byte("x"), 1000)
+	file := filepath.Join(t.TempDir(), "download.bin")
+	if err := os.WriteFile(file, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var source *closableStream
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		if !c.Response().IsBodyStream() {
+			t.Error("expected the stream to be kept rather than buffered")
+		}
+		return err
+	})
+	app.Use(BandwidthMiddleware(strategy, func(c *fiber.Ctx) string { return c.Path() }, WithResponseBodyCharge()))
+	app.Get("/file", func(c *fiber.Ctx) error { return c.SendFile(file) })
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		source = &closableStream{Reader: &streamOnly{bytes.NewReader(body)}}
+		c.Response().SetBodyStream(source, -1)
+		return nil
+	})
+
+	for _, path := range []string{"/file", "/stream"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if got, _ := io.ReadAll(resp.Body); !bytes.Equal(got, body) {
+			t.Fatalf("%s: expected the full body, got %d bytes", path, len(got))
+		}
+		if state, _ := strategy.Inspect(path); state.Tokens != -900 {
+			t.Fatalf("%s: expected a debt of 900 bytes, got %v tokens", path, state.Tokens)
+		}
+	}
+	if source.closed != 1 {
+		t.Fatalf("expected the stream to be closed once, got %d", source.closed)
+	}
+}
+
+// weightedOnly hides the token bucket's Inspector, so the capacity is unknown.
+type weightedOnly struct{ strategies.Weighted }
+
+func TestPacedReaderHalvesChunksOverCapacity(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(1000, 10)
+	strategy.Clock = clock
+	body := bytes.Repeat([]byte("x"), 100)
+	reader := &pacedReader{
+		source:   bytes.NewReader(body),
+		strategy: weightedOnly{strategy},
+		key:      "client",
+		chunk:    64,
+		sleep:    clock.Advance,
+	}
+
+	got, err := io.ReadAll(reader)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Fatalf("expected the full body, got %d bytes and %v", len(got), err)
+	}
+	if reader.chunk > 10 {
+		t.Fatalf("expected chunks to shrink to the 10 byte capacity, got %d", reader.chunk)
+	}
+}
+
+func TestPacedReaderStallsWithoutRefill(t *testing.T) {
+	strategy := strategies.NewTokenBucketStrategy(0, 10)
+	reader := &pacedReader{
+		source:   bytes.NewReader(bytes.Repeat([]byte("x"), 100)),
+		strategy: strategy,
+		key:      "client",
+		chunk:    10,
+		sleep:    func(time.Duration) { t.Fatal("expected no wait for a bucket that never refills") },
+	}
+
+	if _, err := io.ReadAll(reader); !errors.Is(err, ErrPacingStalled) {
+		t.Fatalf("expected ErrPacingStalled, got %v", err)
+	}
+}
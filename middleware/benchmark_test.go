@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+func benchmarkApp(b *testing.B, app *fiber.App) {
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			b.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+}
+
+// BenchmarkBaseline measures app.Test without any limiter for comparison.
+func BenchmarkBaseline(b *testing.B) {
+	benchmarkApp(b, fiber.New())
+}
+
+func BenchmarkRateLimitingMiddleware(b *testing.B) {
+	b.Run("allowed", func(b *testing.B) {
+		app := fiber.New()
+		app.Use(RateLimitingMiddleware(strategies.NewTokenBucketStrategy(1e9, 1e9), func(c *fiber.Ctx) string { return c.IP() }))
+		benchmarkApp(b, app)
+	})
+
+	b.Run("rejected", func(b *testing.B) {
+		app := fiber.New()
+		app.Use(RateLimitingMiddleware(strategies.NewFixedWindowStrategy(0, time.Hour), func(c *fiber.Ctx) string { return c.IP() }))
+		benchmarkApp(b, app)
+	})
+}
@@ -0,0 +1,107 @@
+// Package fiberv3 provides the rate limiting middleware for Fiber v3, whose
+// handlers take the fiber.Ctx interface instead of *fiber.Ctx. It shares the
+// strategies with the Fiber v2 middleware in the parent package, so services
+// can migrate one at a time while keeping their limits and state.
+//
+// It lives in its own module because Fiber v3 requires a newer Go version
+// than the rest of the library.
+package fiberv3
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v3"
+)
+
+// MIMEApplicationProblemJSON is the RFC 9457 problem details media type.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ProblemDetails is the RFC 9457 body written for problem+json responses. It
+// matches the Fiber v2 middleware's.
+type ProblemDetails struct {
+	Type       string  `json:"type"`
+	Title      string  `json:"title"`
+	Status     int     `json:"status"`
+	Detail     string  `json:"detail"`
+	RetryAfter int64   `json:"retryAfter,omitempty"`
+	Limit      float64 `json:"limit,omitempty"`
+}
+
+// RateLimitingMiddleware creates a Fiber v3 middleware that applies rate
+// limiting using the provided strategy and client ID resolver function.
+//
+// Parameters:
+//   - strategy: RateLimitStrategy that defines how rate limits are enforced.
+//   - clientIdResolver: function to extract a unique client ID from the request.
+//   - opts: optional settings such as WithGlobalStrategy and WithSkipFailedRequests.
+//
+// Returns:
+//   - fiber.Handler: the middleware function that checks rate limits.
+//
+// If the client exceeds the allowed rate, the middleware responds with HTTP 429
+// and a Retry-After header, as plain text or, if the client accepts it,
+// problem+json. Otherwise, it passes the request to the next handler.
+// Strategies implementing strategies.Observer are told the latency and status
+// of every admitted request. It panics if the options require a capability,
+// such as refunds, that the strategy does not implement.
+func RateLimitingMiddleware(strategy strategies.RateLimitStrategy, clientIdResolver func(fiber.Ctx) string, opts ...Option) fiber.Handler {
+	l := newConfig(opts).limiter(strategy)
+
+	return func(c fiber.Ctx) error {
+		return limit(c, l, clientIdResolver(c))
+	}
+}
+
+func limit(c fiber.Ctx, l *limiter.Limiter, key string) error {
+	admission, denial := l.Allow(key)
+	if denial != nil {
+		return reject(c, denial)
+	}
+	admissions, _ := c.Locals(admissionsKey{}).([]*limiter.Admission)
+	c.Locals(admissionsKey{}, append(admissions, admission))
+
+	start := time.Now()
+	err := c.Next()
+	admission.Finish(time.Since(start), responseStatus(c, err))
+	return err
+}
+
+// reject writes the 429 for a denied request.
+func reject(c fiber.Ctx, denial *limiter.Denial) error {
+	wait := denial.RetryAfter()
+	if value := limiter.RetryAfterHeader(wait); value != "" {
+		c.Set(fiber.HeaderRetryAfter, value)
+	}
+	c.Vary(fiber.HeaderAccept)
+	c.Status(fiber.StatusTooManyRequests)
+
+	const detail = "Rate limit exceeded."
+	if c.Accepts(fiber.MIMETextPlain, MIMEApplicationProblemJSON, fiber.MIMEApplicationJSON) == fiber.MIMETextPlain {
+		return c.SendString(detail)
+	}
+
+	return c.JSON(ProblemDetails{
+		Type:       "about:blank",
+		Title:      "Too Many Requests",
+		Status:     fiber.StatusTooManyRequests,
+		Detail:     detail,
+		RetryAfter: limiter.RetryAfterSeconds(wait),
+		Limit:      denial.Limit(),
+	}, MIMEApplicationProblemJSON)
+}
+
+// responseStatus returns the status the client will receive, accounting for
+// errors that the app's error handler has yet to turn into a response.
+func responseStatus(c fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
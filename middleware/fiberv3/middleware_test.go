@@ -0,0 +1,100 @@
+package fiberv3
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v3"
+)
+
+func request(t *testing.T, app *fiber.App, target, accept string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set(fiber.HeaderAccept, accept)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return resp
+}
+
+func TestMiddlewareRejectsWithRetryAfter(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, Header("X-API-Key")))
+	app.Get("/", func(c fiber.Ctx) error { return c.SendString("ok") })
+
+	if resp := request(t, app, "/", ""); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	resp := request(t, app, "/", "")
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "60" {
+		t.Fatalf("expected Retry-After=60, got %q", got)
+	}
+	if got := resp.Header.Get(fiber.HeaderContentType); got != fiber.MIMETextPlainCharsetUTF8 {
+		t.Fatalf("expected plain text by default, got %q", got)
+	}
+}
+
+func TestMiddlewareNegotiatesProblemJSON(t *testing.T) {
+	strategy := strategies.NewSlidingWindowStrategy(0, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, IP))
+
+	resp := request(t, app, "/", MIMEApplicationProblemJSON)
+	if got := resp.Header.Get(fiber.HeaderContentType); got != MIMEApplicationProblemJSON {
+		t.Fatalf("expected problem+json, got %q", got)
+	}
+	var problem ProblemDetails
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if problem.Status != fiber.StatusTooManyRequests || problem.Title != "Too Many Requests" {
+		t.Fatalf("unexpected problem: %+v", problem)
+	}
+}
+
+func TestMiddlewareGlobalStrategyAndSkipOptions(t *testing.T) {
+	perClient := strategies.NewSlidingWindowStrategy(5, time.Minute)
+	global := strategies.NewSlidingWindowStrategy(2, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(perClient, Header("X-API-Key"), WithGlobalStrategy(global), WithSkipFailedRequests()))
+	app.Get("/ok", func(c fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/fail", func(c fiber.Ctx) error { return fiber.ErrBadGateway })
+
+	request(t, app, "/fail", "")
+	request(t, app, "/ok", "")
+	request(t, app, "/ok", "")
+	if resp := request(t, app, "/ok", ""); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("expected the global limit to reject, got %d", resp.StatusCode)
+	}
+	if state, _ := perClient.Inspect(""); state.Count != 2 {
+		t.Fatalf("expected only the 2 successful requests to count, got %d", state.Count)
+	}
+}
+
+func TestRefundFromHandler(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, IP))
+	app.Get("/cached", func(c fiber.Ctx) error {
+		if !Refund(c) || Refund(c) {
+			t.Error("expected exactly one refund")
+		}
+		return c.SendString("cached")
+	})
+
+	for i := 0; i < 3; i++ {
+		if resp := request(t, app, "/cached", ""); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d: expected refunded requests to stay admitted, got %d", i, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package fiberv3
+
+import (
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+// GlobalKey is the key under which the strategy passed to WithGlobalStrategy
+// is evaluated. It matches the Fiber v2 middleware's, so both can share a
+// global strategy during a migration.
+const GlobalKey = limiter.GlobalKey
+
+// Option configures RateLimitingMiddleware.
+type Option func(*config)
+
+type config struct {
+	global                 strategies.RateLimitStrategy
+	skipFailedRequests     bool
+	skipSuccessfulRequests bool
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithGlobalStrategy adds a limit shared by all clients, evaluated under
+// GlobalKey after the per-client strategy admits a request. If it rejects, the
+// per-client unit is refunded. The per-client strategy must implement
+// strategies.Refunder.
+func WithGlobalStrategy(strategy strategies.RateLimitStrategy) Option {
+	return func(cfg *config) {
+		cfg.global = strategy
+	}
+}
+
+// WithSkipFailedRequests refunds requests whose response status is >= 400, so
+// only successful requests count against the limit.
+func WithSkipFailedRequests() Option {
+	return func(cfg *config) {
+		cfg.skipFailedRequests = true
+	}
+}
+
+// WithSkipSuccessfulRequests refunds requests whose response status is < 400,
+// so only failed requests count against the limit.
+func WithSkipSuccessfulRequests() Option {
+	return func(cfg *config) {
+		cfg.skipSuccessfulRequests = true
+	}
+}
+
+// limiter returns the limiter applying the options to strategy. It panics if
+// the options require capabilities strategy lacks.
+func (cfg *config) limiter(strategy strategies.RateLimitStrategy) *limiter.Limiter {
+	l := &limiter.Limiter{
+		Strategy:               strategy,
+		Global:                 cfg.global,
+		SkipFailedRequests:     cfg.skipFailedRequests,
+		SkipSuccessfulRequests: cfg.skipSuccessfulRequests,
+	}
+	if err := l.Validate(); err != nil {
+		panic("fiberv3: " + err.Error())
+	}
+	return l
+}
@@ -0,0 +1,23 @@
+package fiberv3
+
+import (
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gofiber/fiber/v3"
+)
+
+type admissionsKey struct{}
+
+// Refund gives back the unit charged for the current request by every
+// rate limiting middleware that admitted it, e.g. after a cache hit. It
+// returns true if at least one unit was refunded. A request is never refunded
+// more than it was charged.
+func Refund(c fiber.Ctx) bool {
+	admissions, _ := c.Locals(admissionsKey{}).([]*limiter.Admission)
+	refunded := false
+	for _, admission := range admissions {
+		if admission.Refund() {
+			refunded = true
+		}
+	}
+	return refunded
+}
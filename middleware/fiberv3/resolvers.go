@@ -0,0 +1,20 @@
+package fiberv3
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// IP resolves the client key to c.IP().
+func IP(c fiber.Ctx) string {
+	return c.IP()
+}
+
+// Header returns a resolver reading the named request header, e.g.
+// Header("X-API-Key").
+func Header(name string) func(fiber.Ctx) string {
+	return func(c fiber.Ctx) string {
+		return strings.TrimSpace(c.Get(name))
+	}
+}
@@ -0,0 +1,157 @@
+// Package grpclimiter provides gRPC server interceptors backed by the rate
+// limiting strategies. Rejected calls fail with RESOURCE_EXHAUSTED carrying a
+// google.rpc.RetryInfo detail, and a retry-after response header in whole
+// seconds mirrors the HTTP Retry-After convention.
+package grpclimiter
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader is the response header carrying the retry delay in seconds.
+const RetryAfterHeader = "retry-after"
+
+// Resolver extracts a client key from an incoming call.
+type Resolver func(ctx context.Context, fullMethod string) string
+
+// UnaryServerInterceptor rate limits unary calls.
+//
+// Parameters:
+//   - strategy: RateLimitStrategy that defines how rate limits are enforced.
+//   - resolver: function to extract a unique client ID from the call, e.g. PeerIP.
+//
+// Returns:
+//   - grpc.UnaryServerInterceptor: the interceptor to pass to grpc.ChainUnaryInterceptor.
+//
+// Strategies implementing strategies.Observer are told the latency of every
+// admitted call and its status mapped to the equivalent HTTP status.
+func UnaryServerInterceptor(strategy strategies.RateLimitStrategy, resolver Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key := resolver(ctx, info.FullMethod)
+		if !strategy.IsRequestAllowed(key) {
+			wait := strategy.RetryAfter(key)
+			if value := limiter.RetryAfterHeader(wait); value != "" {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, value))
+			}
+			return nil, rejection(wait)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(strategy, time.Since(start), err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor rate limits the opening of streams; messages within
+// an admitted stream are not counted.
+//
+// Parameters:
+//   - strategy: RateLimitStrategy that defines how rate limits are enforced.
+//   - resolver: function to extract a unique client ID from the call, e.g. PeerIP.
+//
+// Returns:
+//   - grpc.StreamServerInterceptor: the interceptor to pass to grpc.ChainStreamInterceptor.
+func StreamServerInterceptor(strategy strategies.RateLimitStrategy, resolver Resolver) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		key := resolver(stream.Context(), info.FullMethod)
+		if !strategy.IsRequestAllowed(key) {
+			wait := strategy.RetryAfter(key)
+			if value := limiter.RetryAfterHeader(wait); value != "" {
+				_ = stream.SetHeader(metadata.Pairs(RetryAfterHeader, value))
+			}
+			return rejection(wait)
+		}
+
+		start := time.Now()
+		err := handler(srv, stream)
+		observe(strategy, time.Since(start), err)
+		return err
+	}
+}
+
+// PeerIP resolves the client key to the IP address of the calling peer.
+func PeerIP(ctx context.Context, fullMethod string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// Metadata returns a resolver reading the first value of the named incoming
+// metadata key, e.g. Metadata("x-api-key").
+func Metadata(name string) Resolver {
+	return func(ctx context.Context, fullMethod string) string {
+		if values := metadata.ValueFromIncomingContext(ctx, name); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+}
+
+// rejection builds the RESOURCE_EXHAUSTED status for a rejected call.
+func rejection(wait time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if wait <= 0 {
+		return st.Err()
+	}
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func observe(strategy strategies.RateLimitStrategy, latency time.Duration, err error) {
+	if observer, ok := strategy.(strategies.Observer); ok {
+		observer.Observe(latency, httpStatus(status.Code(err)))
+	}
+}
+
+// httpStatus maps a gRPC code to the HTTP status gRPC gateways use for it, so
+// observers can treat 5xx as server failures.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return 200
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return 400
+	case codes.Unauthenticated:
+		return 401
+	case codes.PermissionDenied:
+		return 403
+	case codes.NotFound:
+		return 404
+	case codes.AlreadyExists, codes.Aborted:
+		return 409
+	case codes.ResourceExhausted:
+		return 429
+	case codes.Unimplemented:
+		return 501
+	case codes.Unavailable:
+		return 503
+	case codes.DeadlineExceeded:
+		return 504
+	default:
+		return 500
+	}
+}
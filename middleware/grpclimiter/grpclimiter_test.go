@@ -0,0 +1,124 @@
+package grpclimiter
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newClient serves the health service behind the interceptors on an
+// in-process listener and returns a client for it.
+func newClient(t *testing.T, opts ...grpc.ServerOption) healthpb.HealthClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	client := newClient(t, grpc.ChainUnaryInterceptor(UnaryServerInterceptor(strategy, Metadata("x-api-key"))))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "k1")
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected the first call to succeed, got %v", err)
+	}
+
+	var header metadata.MD
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("expected RESOURCE_EXHAUSTED, got %v", err)
+	}
+	if values := header.Get(RetryAfterHeader); len(values) != 1 || values[0] != "60" {
+		t.Fatalf("expected retry-after header 60, got %v", values)
+	}
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	if retryInfo == nil || retryInfo.RetryDelay.AsDuration() <= 0 || retryInfo.RetryDelay.AsDuration() > time.Minute {
+		t.Fatalf("expected a RetryInfo detail within the window, got %v", st.Details())
+	}
+
+	// Other keys are limited independently.
+	other := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "k2")
+	if _, err := client.Check(other, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected another key to be admitted, got %v", err)
+	}
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	client := newClient(t, grpc.ChainStreamInterceptor(StreamServerInterceptor(strategy, PeerIP)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if _, err := first.Recv(); err != nil {
+		t.Fatalf("expected the first stream to be admitted, got %v", err)
+	}
+
+	second, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if _, err := second.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected the second stream to be rejected, got %v", err)
+	}
+	if header, _ := second.Header(); len(header.Get(RetryAfterHeader)) != 1 {
+		t.Fatalf("expected a retry-after header, got %v", header)
+	}
+}
+
+// recordingObserver admits everything and records observed statuses.
+type recordingObserver struct {
+	statuses []int
+}
+
+func (r *recordingObserver) IsRequestAllowed(string) bool      { return true }
+func (r *recordingObserver) RetryAfter(string) time.Duration   { return 0 }
+func (r *recordingObserver) Observe(_ time.Duration, code int) { r.statuses = append(r.statuses, code) }
+
+func TestUnaryInterceptorReportsToObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	client := newClient(t, grpc.ChainUnaryInterceptor(UnaryServerInterceptor(observer, PeerIP)))
+
+	client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+
+	if len(observer.statuses) != 2 || observer.statuses[0] != 200 || observer.statuses[1] != 404 {
+		t.Fatalf("expected observed statuses [200 404], got %v", observer.statuses)
+	}
+}
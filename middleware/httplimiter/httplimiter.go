@@ -0,0 +1,143 @@
+// Package httplimiter adapts the rate limiting strategies to net/http
+// handlers, following the same conventions as the Fiber middleware: HTTP 429
+// with a Retry-After header in whole seconds, rounded up.
+package httplimiter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+// Resolver extracts a client key from a request.
+type Resolver func(*http.Request) string
+
+// Option configures Middleware.
+type Option func(*config)
+
+type config struct {
+	global strategies.RateLimitStrategy
+}
+
+// WithGlobalStrategy adds a limit shared by all clients, evaluated under
+// limiter.GlobalKey after the per-client strategy admits a request. If it
+// rejects, the per-client unit is refunded. The per-client strategy must
+// implement strategies.Refunder.
+func WithGlobalStrategy(strategy strategies.RateLimitStrategy) Option {
+	return func(cfg *config) {
+		cfg.global = strategy
+	}
+}
+
+// Middleware creates net/http middleware that applies rate limiting using the
+// provided strategy and client ID resolver.
+//
+// Parameters:
+//   - strategy: RateLimitStrategy that defines how rate limits are enforced.
+//   - resolver: function to extract a unique client ID from the request, e.g. IP.
+//   - opts: optional settings such as WithGlobalStrategy.
+//
+// Returns:
+//   - func(http.Handler) http.Handler: the middleware wrapping a handler.
+//
+// Rejected requests get HTTP 429 and, unless immediately retryable, a
+// Retry-After header. Strategies implementing strategies.Observer are told the
+// latency and status of every admitted request. It panics if a global
+// strategy is set and strategy does not implement strategies.Refunder.
+func Middleware(strategy strategies.RateLimitStrategy, resolver Resolver, opts ...Option) func(http.Handler) http.Handler {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	l := &limiter.Limiter{Strategy: strategy, Global: cfg.global}
+	if err := l.Validate(); err != nil {
+		panic("httplimiter: " + err.Error())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			admission, denial := l.Allow(resolver(r))
+			if denial != nil {
+				reject(w, denial.RetryAfter())
+				return
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(recorder, r)
+			admission.Finish(time.Since(start), recorder.status)
+		})
+	}
+}
+
+// IP resolves the client key to the host part of r.RemoteAddr. Use a
+// resolver aware of your proxies if the server sits behind one.
+func IP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Header returns a resolver reading the named request header.
+func Header(name string) Resolver {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// reject writes a plain-text 429 with Retry-After, matching the Fiber
+// middleware's default response.
+func reject(w http.ResponseWriter, wait time.Duration) {
+	if value := limiter.RetryAfterHeader(wait); value != "" {
+		w.Header().Set("Retry-After", value)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	io.WriteString(w, "Rate limit exceeded.")
+}
+
+// statusRecorder captures the status written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes through to the underlying writer so streaming responses, such
+// as server-sent events, keep working.
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through to the underlying writer so connections can be
+// upgraded, e.g. to WebSocket.
+func (recorder *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := recorder.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httplimiter: %T does not implement http.Hijacker", recorder.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
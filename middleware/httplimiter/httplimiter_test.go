@@ -0,0 +1,113 @@
+package httplimiter
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+func serve(handler http.Handler, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareRejectsWithRetryAfter(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	handler := Middleware(strategy, Header("X-API-Key"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	if rec := serve(handler, "k1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	rec := serve(handler, "k1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Fatalf("expected Retry-After=60, got %q", got)
+	}
+	// The body matches the Fiber middleware's default plain-text rejection.
+	if got := rec.Body.String(); got != "Rate limit exceeded." {
+		t.Fatalf("expected the Fiber rejection body, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Fatalf("expected plain text, got %q", got)
+	}
+	if rec := serve(handler, "k2"); rec.Code != http.StatusOK {
+		t.Fatalf("expected another key to be admitted, got %d", rec.Code)
+	}
+}
+
+func TestMiddlewareGlobalStrategy(t *testing.T) {
+	perClient := strategies.NewSlidingWindowStrategy(5, time.Minute)
+	global := strategies.NewSlidingWindowStrategy(1, time.Minute)
+	handler := Middleware(perClient, Header("X-API-Key"), WithGlobalStrategy(global))(http.NotFoundHandler())
+
+	serve(handler, "k1")
+	if rec := serve(handler, "k2"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the global limit to reject, got %d", rec.Code)
+	}
+	if state, _ := perClient.Inspect("k2"); state.Count != 0 {
+		t.Fatalf("expected k2's unit to be refunded, got %d", state.Count)
+	}
+	if state, _ := global.Inspect(limiter.GlobalKey); state.Count != 1 {
+		t.Fatalf("expected the global strategy to be keyed by limiter.GlobalKey, got %+v", state)
+	}
+}
+
+func TestIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[2001:db8::1]:4321"
+	if got := IP(req); got != "2001:db8::1" {
+		t.Fatalf("expected 2001:db8::1, got %q", got)
+	}
+}
+
+func TestMiddlewarePassesThroughFlushAndHijack(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(10, time.Minute)
+	handler := Middleware(strategy, IP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/upgrade" {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack failed: %v", err)
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\n"))
+			conn.Close()
+			return
+		}
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if !rec.Flushed {
+		t.Fatal("expected the flush to reach the underlying writer")
+	}
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /upgrade HTTP/1.1\r\nHost: test\r\n\r\n"))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "HTTP/1.1 101 Switching Protocols\r\n" {
+		t.Fatalf("expected the hijacked connection's response, got %q (%v)", line, err)
+	}
+}
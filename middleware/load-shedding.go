@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoadSheddingMiddleware creates a Fiber middleware that sheds traffic by
+// priority class when the service as a whole is over capacity.
+//
+// Parameters:
+//   - strategy: a global strategy keyed by class, typically a
+//     strategies.PriorityStrategy.
+//   - classifier: function mapping a request to its priority class, e.g.
+//     resolvers.Classify(resolvers.Header("X-Priority"), ...).
+//   - opts: the same options accepted by RateLimitingMiddleware.
+//
+// Returns:
+//   - fiber.Handler: the middleware function that sheds load.
+//
+// Shed requests are answered with HTTP 503 and a Retry-After header, rendered
+// like other rejections; their ClientId is the class. Access lists match the
+// class name. Place it before per-client rate limiting so shed requests do not
+// consume client allowances.
+func LoadSheddingMiddleware(strategy strategies.RateLimitStrategy, classifier func(*fiber.Ctx) string, opts ...Option) fiber.Handler {
+	cfg := newConfig(opts)
+	cfg.rejection = Rejection{
+		Status: fiber.StatusServiceUnavailable,
+		Title:  "Service Unavailable",
+		Detail: "Server is overloaded.",
+	}
+	l := cfg.limiter(strategy)
+
+	return func(c *fiber.Ctx) error {
+		class := classifier(c)
+		return cfg.limit(c, l, class, class)
+	}
+}
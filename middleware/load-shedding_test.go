@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLoadSheddingRejectsWith503(t *testing.T) {
+	strategy := strategies.NewPriorityStrategy(1, 2,
+		strategies.PriorityClass{Name: "critical", Share: 1},
+		strategies.PriorityClass{Name: "batch", Share: 1},
+	)
+	strategy.Reserve = 0
+	app := fiber.New()
+	app.Use(LoadSheddingMiddleware(strategy, func(c *fiber.Ctx) string { return c.Get("X-Class") }))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	request := func(class string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Class", class)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		return resp
+	}
+
+	// batch borrows critical's only token, then is shed.
+	for i, expected := range []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusServiceUnavailable} {
+		if resp := request("batch"); resp.StatusCode != expected {
+			t.Fatalf("request %d: expected %d, got %d", i, expected, resp.StatusCode)
+		}
+	}
+	resp := request("critical")
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("expected critical to be shed once every token is used, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get(fiber.HeaderRetryAfter) == "" {
+		t.Fatal("expected Retry-After on shed requests")
+	}
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"errors"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
 	"github.com/gabisonia/fiber-rate-limiter/strategies"
 	"github.com/gofiber/fiber/v2"
-	"strconv"
 )
 
 // RateLimitingMiddleware creates a Fiber middleware that applies rate limiting
@@ -12,28 +15,83 @@ import (
 // Parameters:
 //   - strategy: RateLimitStrategy that defines how rate limits are enforced.
 //   - clientIdResolver: function to extract a unique client ID from the request.
+//   - opts: optional settings such as WithAllowlist, WithDenylist, WithRenderer
+//     and WithGlobalStrategy.
 //
 // Returns:
 //   - fiber.Handler: the middleware function that checks rate limits.
 //
-// If the client exceeds the allowed rate, the middleware responds with HTTP 429.
-// Otherwise, it passes the request to the next handler.
-func RateLimitingMiddleware(strategy strategies.RateLimitStrategy, clientIdResolver func(*fiber.Ctx) string) fiber.Handler {
+// If the client exceeds the allowed rate, the middleware responds with HTTP 429
+// in the format negotiated from the Accept header. Otherwise, it passes the
+// request to the next handler. Strategies implementing strategies.Observer are
+// told the latency and status of every admitted request. It panics if the
+// options require a capability, such as refunds, that the strategy does not
+// implement.
+func RateLimitingMiddleware(strategy strategies.RateLimitStrategy, clientIdResolver func(*fiber.Ctx) string, opts ...Option) fiber.Handler {
+	cfg := newConfig(opts)
+	l := cfg.limiter(strategy)
+
 	return func(c *fiber.Ctx) error {
 		clientId := clientIdResolver(c)
+		return cfg.limit(c, l, clientId, clientId)
+	}
+}
 
-		if !strategy.IsRequestAllowed(clientId) {
-			if wait := strategy.RetryAfter(clientId); wait > 0 {
-				// Retry-After accepts seconds; round up to be conservative.
-				seconds := int64(wait.Seconds())
-				if wait.Seconds() > float64(seconds) {
-					seconds++
-				}
-				c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds, 10))
-			}
-			return c.Status(fiber.StatusTooManyRequests).SendString("Rate limit exceeded.")
-		}
-
-		return c.Next()
+// limit applies the access lists to clientId and l to key, then either rejects
+// the request or passes it on.
+func (cfg *config) limit(c *fiber.Ctx, l *limiter.Limiter, clientId, key string) error {
+	if handled, err := cfg.screen(c, clientId); handled {
+		return err
+	}
+
+	admission, denial := l.Allow(key)
+	if denial != nil {
+		return cfg.reject(c, denial, clientId)
+	}
+	admissions, _ := c.Locals(admissionsKey{}).([]*limiter.Admission)
+	c.Locals(admissionsKey{}, append(admissions, admission))
+
+	start := time.Now()
+	err := c.Next()
+	admission.Finish(time.Since(start), responseStatus(c, err))
+	return err
+}
+
+// screen applies the access lists to clientId. It reports whether the request
+// was handled, either rejected by the denylist or passed on by the allowlist.
+func (cfg *config) screen(c *fiber.Ctx, clientId string) (bool, error) {
+	if cfg.denylist != nil && cfg.denylist.Contains(clientId, c.IP()) {
+		return true, cfg.render(c, Rejection{
+			Status:   fiber.StatusForbidden,
+			Title:    "Forbidden",
+			Detail:   "Access denied.",
+			ClientId: clientId,
+		})
+	}
+	if cfg.allowlist != nil && cfg.allowlist.Contains(clientId, c.IP()) {
+		return true, c.Next()
+	}
+	return false, nil
+}
+
+// reject renders the rejection for a denied request.
+func (cfg *config) reject(c *fiber.Ctx, denial *limiter.Denial, clientId string) error {
+	rejection := cfg.rejection
+	rejection.ClientId = clientId
+	rejection.RetryAfter = denial.RetryAfter()
+	rejection.Limit = denial.Limit()
+	return cfg.render(c, rejection)
+}
+
+// responseStatus returns the status the client will receive, accounting for
+// errors that the app's error handler has yet to turn into a response.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
 	}
+	return fiber.StatusInternalServerError
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -73,3 +74,204 @@ func TestMiddlewarePassesThroughWhenAllowed(t *testing.T) {
 		t.Fatalf("expected no Retry-After header, got %q", got)
 	}
 }
+
+func TestMiddlewareDenylistRejectsWithForbidden(t *testing.T) {
+	denylist, _ := NewAccessList("abuser")
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(fakeStrategy{allow: true}, func(*fiber.Ctx) string { return "abuser" }, WithDenylist(denylist)))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestMiddlewareAllowlistBypassesLimit(t *testing.T) {
+	// app.Test requests originate from 0.0.0.0
+	allowlist, _ := NewAccessList("0.0.0.0/8")
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(fakeStrategy{allow: false, wait: time.Second}, func(*fiber.Ctx) string { return "client" }, WithAllowlist(allowlist)))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// removing the range at runtime re-enables limiting
+	_ = allowlist.Replace()
+	resp, err = app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("expected 429 after reload, got %d", resp.StatusCode)
+	}
+}
+
+// A client id that equals a listed address must not bypass the limit when the
+// request comes from elsewhere.
+func TestMiddlewareAllowlistIgnoresAddressesAsKeys(t *testing.T) {
+	allowlist, _ := NewAccessList("10.0.0.5")
+	resolver := func(c *fiber.Ctx) string {
+		if key := c.Get("X-API-Key"); key != "" {
+			return key
+		}
+		return c.IP()
+	}
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategies.NewFixedWindowStrategy(0, time.Minute), resolver, WithAllowlist(allowlist)))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "10.0.0.5")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", resp.StatusCode)
+	}
+}
+
+func TestMiddlewareSkipFailedRequests(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, func(*fiber.Ctx) string { return "client" }, WithSkipFailedRequests()))
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrBadRequest })
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	expectStatus(t, app, "/fail", fiber.StatusBadRequest)
+	expectStatus(t, app, "/fail", fiber.StatusBadRequest)
+	expectStatus(t, app, "/ok", fiber.StatusOK)
+	expectStatus(t, app, "/ok", fiber.StatusTooManyRequests)
+}
+
+func TestMiddlewareSkipSuccessfulRequests(t *testing.T) {
+	strategy := strategies.NewTokenBucketStrategy(0, 2)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, func(*fiber.Ctx) string { return "client" }, WithSkipSuccessfulRequests()))
+	app.Get("/login", func(c *fiber.Ctx) error {
+		if c.Query("password") == "secret" {
+			return c.SendString("welcome")
+		}
+		return c.SendStatus(fiber.StatusUnauthorized)
+	})
+
+	for i := 0; i < 5; i++ {
+		expectStatus(t, app, "/login?password=secret", fiber.StatusOK)
+	}
+	expectStatus(t, app, "/login?password=guess", fiber.StatusUnauthorized)
+	expectStatus(t, app, "/login?password=guess", fiber.StatusUnauthorized)
+	expectStatus(t, app, "/login?password=secret", fiber.StatusTooManyRequests)
+}
+
+func TestMiddlewareSkipRequiresRefunder(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for strategy without refunds")
+		}
+	}()
+	RateLimitingMiddleware(fakeStrategy{allow: true}, func(*fiber.Ctx) string { return "client" }, WithSkipFailedRequests())
+}
+
+func expectStatus(t *testing.T, app *fiber.App, target string, want int) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, target, nil)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != want {
+		t.Fatalf("GET %s: expected %d, got %d", target, want, resp.StatusCode)
+	}
+}
+
+// observingStrategy admits everything and records what the middleware observes.
+type observingStrategy struct {
+	fakeStrategy
+	statuses []int
+}
+
+func (o *observingStrategy) Observe(latency time.Duration, status int) {
+	o.statuses = append(o.statuses, status)
+}
+
+func TestMiddlewareReportsAdmittedRequestsToObserver(t *testing.T) {
+	strategy := &observingStrategy{fakeStrategy: fakeStrategy{allow: true}}
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, func(*fiber.Ctx) string { return "client" }))
+	app.Get("/ok", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/fail", func(c *fiber.Ctx) error { return fiber.ErrServiceUnavailable })
+
+	for _, path := range []string{"/ok", "/fail"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if _, err := app.Test(req, -1); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+
+	if len(strategy.statuses) != 2 || strategy.statuses[0] != fiber.StatusOK || strategy.statuses[1] != fiber.StatusServiceUnavailable {
+		t.Fatalf("expected observed statuses [200 503], got %v", strategy.statuses)
+	}
+}
+
+func TestMiddlewareGlobalStrategy(t *testing.T) {
+	perClient := strategies.NewFixedWindowStrategy(2, time.Minute)
+	global := strategies.NewFixedWindowStrategy(3, time.Minute)
+
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(perClient, func(c *fiber.Ctx) string { return c.Query("client") }, WithGlobalStrategy(global)))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	expectStatus(t, app, "/?client=a", fiber.StatusOK)
+	expectStatus(t, app, "/?client=a", fiber.StatusOK)
+	// a's own limit rejects without touching the global count.
+	expectStatus(t, app, "/?client=a", fiber.StatusTooManyRequests)
+	expectStatus(t, app, "/?client=b", fiber.StatusOK)
+	// The global limit of 3 is reached, so b is rejected and refunded.
+	expectStatus(t, app, "/?client=b", fiber.StatusTooManyRequests)
+
+	if state, _ := perClient.Inspect("b"); state.Count != 1 {
+		t.Fatalf("expected b to be charged only for its admitted request, got %d", state.Count)
+	}
+	if state, _ := global.Inspect(GlobalKey); state.Count != 3 {
+		t.Fatalf("expected 3 global admissions, got %d", state.Count)
+	}
+}
+
+func TestMiddlewareGlobalStrategyRequiresRefunder(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a per-client strategy without refunds")
+		}
+	}()
+	RateLimitingMiddleware(fakeStrategy{allow: true}, func(*fiber.Ctx) string { return "client" }, WithGlobalStrategy(fakeStrategy{allow: true}))
+}
+
+func TestMiddlewareSkipRefundsGlobalStrategy(t *testing.T) {
+	perClient := strategies.NewSlidingWindowStrategy(5, time.Minute)
+	global := strategies.NewSlidingWindowStrategy(5, time.Minute)
+
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(perClient, func(*fiber.Ctx) string { return "client" }, WithGlobalStrategy(global), WithSkipFailedRequests()))
+	app.Get("/", func(c *fiber.Ctx) error { return fiber.ErrBadGateway })
+
+	expectStatus(t, app, "/", fiber.StatusBadGateway)
+
+	if state, _ := perClient.Inspect("client"); state.Count != 0 {
+		t.Fatalf("expected the per-client unit to be refunded, got %d", state.Count)
+	}
+	if state, _ := global.Inspect(GlobalKey); state.Count != 0 {
+		t.Fatalf("expected the global unit to be refunded, got %d", state.Count)
+	}
+}
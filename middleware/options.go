@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Option configures RateLimitingMiddleware.
+type Option func(*config)
+
+type config struct {
+	allowlist              *AccessList
+	denylist               *AccessList
+	mediaTypes             []string
+	renderers              map[string]Renderer
+	skipFailedRequests     bool
+	skipSuccessfulRequests bool
+	// rejection is the template for requests the strategy denies.
+	rejection Rejection
+	global    strategies.RateLimitStrategy
+	// Bandwidth settings, used by BandwidthMiddleware.
+	chargeRequestBody  bool
+	chargeResponseBody bool
+	paceChunkSize      int
+	sleep              func(time.Duration)
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		renderers: make(map[string]Renderer),
+		sleep:     time.Sleep,
+		rejection: Rejection{
+			Status: fiber.StatusTooManyRequests,
+			Title:  "Too Many Requests",
+			Detail: "Rate limit exceeded.",
+		},
+	}
+	WithRenderer(fiber.MIMETextPlain, TextRenderer)(cfg)
+	WithRenderer(MIMEApplicationProblemJSON, ProblemRenderer)(cfg)
+	WithRenderer(fiber.MIMEApplicationJSON, ProblemRenderer)(cfg)
+	WithRenderer(fiber.MIMETextHTML, HTMLRenderer(defaultHTMLTemplate))(cfg)
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithAllowlist lets matching clients bypass rate limiting entirely.
+func WithAllowlist(list *AccessList) Option {
+	return func(cfg *config) {
+		cfg.allowlist = list
+	}
+}
+
+// WithDenylist rejects matching clients with HTTP 403 before any rate limit is
+// evaluated. The denylist takes precedence over the allowlist.
+func WithDenylist(list *AccessList) Option {
+	return func(cfg *config) {
+		cfg.denylist = list
+	}
+}
+
+// WithRenderer sets the renderer for rejections negotiated to mediaType,
+// replacing the built-in one if present. Built-in renderers cover text/plain
+// (the default when Accept is absent), application/problem+json,
+// application/json and text/html.
+func WithRenderer(mediaType string, renderer Renderer) Option {
+	return func(cfg *config) {
+		if _, exists := cfg.renderers[mediaType]; !exists {
+			cfg.mediaTypes = append(cfg.mediaTypes, mediaType)
+		}
+		cfg.renderers[mediaType] = renderer
+	}
+}
+
+// WithSkipFailedRequests refunds requests whose response status is >= 400, so
+// only successful requests count against the limit.
+func WithSkipFailedRequests() Option {
+	return func(cfg *config) {
+		cfg.skipFailedRequests = true
+	}
+}
+
+// WithSkipSuccessfulRequests refunds requests whose response status is < 400,
+// so only failed requests count against the limit, e.g. for login endpoints.
+func WithSkipSuccessfulRequests() Option {
+	return func(cfg *config) {
+		cfg.skipSuccessfulRequests = true
+	}
+}
+
+// WithRequestBodyCharge makes BandwidthMiddleware charge the request body
+// size before the handler runs.
+func WithRequestBodyCharge() Option {
+	return func(cfg *config) {
+		cfg.chargeRequestBody = true
+	}
+}
+
+// WithResponseBodyCharge makes BandwidthMiddleware charge the response body
+// size after the handler runs, taking the size of body streams from their
+// Content-Length or, if unknown, counting them as they are sent. The charge
+// may leave the client's allowance in debt, rejecting its requests until the
+// debt is paid off.
+func WithResponseBodyCharge() Option {
+	return func(cfg *config) {
+		cfg.chargeResponseBody = true
+	}
+}
+
+// WithResponsePacing makes BandwidthMiddleware stream response bodies in
+// chunks of at most chunkSize bytes, each sent once the strategy admits it, so
+// large downloads are paced instead of rejected. Chunks are capped at the
+// strategy's capacity, as reported by strategies.Inspector or else found by
+// halving chunks the strategy never admits.
+func WithResponsePacing(chunkSize int) Option {
+	return func(cfg *config) {
+		cfg.paceChunkSize = chunkSize
+	}
+}
+
+// GlobalKey is the key under which the strategy passed to WithGlobalStrategy
+// is evaluated.
+const GlobalKey = limiter.GlobalKey
+
+// WithGlobalStrategy adds a limit shared by all clients, e.g. a token bucket
+// capping the whole service at 5,000 requests per second. It is evaluated
+// under GlobalKey after the per-client strategy admits a request; if it then
+// rejects, the per-client unit is refunded so the client is not charged for a
+// request it never got to make. Rejections carry the global strategy's
+// Retry-After. The per-client strategy must implement strategies.Refunder.
+func WithGlobalStrategy(strategy strategies.RateLimitStrategy) Option {
+	return func(cfg *config) {
+		cfg.global = strategy
+	}
+}
+
+// limiter returns the limiter applying the options to strategy. It panics if
+// the options require capabilities strategy lacks.
+func (cfg *config) limiter(strategy strategies.RateLimitStrategy) *limiter.Limiter {
+	l := &limiter.Limiter{
+		Strategy:               strategy,
+		Global:                 cfg.global,
+		SkipFailedRequests:     cfg.skipFailedRequests,
+		SkipSuccessfulRequests: cfg.skipSuccessfulRequests,
+	}
+	if err := l.Validate(); err != nil {
+		panic("middleware: " + err.Error())
+	}
+	return l
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy binds a strategy to the requests it applies to. Empty matchers match
+// any request.
+type Policy struct {
+	// Name namespaces the policy's keys as "name:clientId"; it must be unique.
+	Name string
+	// Method matches the request method, e.g. "POST".
+	Method string
+	// Host matches the request hostname. A leading "*." matches any subdomain.
+	Host string
+	// Path matches the request path. Segments starting with ":" match any
+	// single segment and a trailing "*" matches the rest of the path, e.g.
+	// "/users/:id/*".
+	Path     string
+	Strategy strategies.RateLimitStrategy
+}
+
+// PolicyRateLimitingMiddleware creates a Fiber middleware that rate limits each
+// request with the first matching policy.
+//
+// Parameters:
+//   - policies: the policy table, evaluated in order.
+//   - clientIdResolver: function to extract a unique client ID from the request.
+//   - opts: the same options accepted by RateLimitingMiddleware.
+//
+// Returns:
+//   - fiber.Handler: the middleware function that checks rate limits.
+//
+// State is keyed by policy name and client, so policies sharing a strategy
+// instance still get independent limits. Requests matching no policy pass
+// through. It panics if a policy has no name or strategy, if names repeat, or
+// if a strategy lacks a capability the options require.
+func PolicyRateLimitingMiddleware(policies []Policy, clientIdResolver func(*fiber.Ctx) string, opts ...Option) fiber.Handler {
+	cfg := newConfig(opts)
+
+	limiters := make([]*limiter.Limiter, len(policies))
+	seen := make(map[string]bool, len(policies))
+	for i, policy := range policies {
+		if policy.Name == "" || policy.Strategy == nil {
+			panic("middleware: policy requires a name and a strategy")
+		}
+		if seen[policy.Name] {
+			panic(fmt.Sprintf("middleware: duplicate policy name %q", policy.Name))
+		}
+		seen[policy.Name] = true
+		limiters[i] = cfg.limiter(policy.Strategy)
+	}
+
+	return func(c *fiber.Ctx) error {
+		for i, policy := range policies {
+			if policy.matches(c) {
+				clientId := clientIdResolver(c)
+				return cfg.limit(c, limiters[i], clientId, policy.Name+":"+clientId)
+			}
+		}
+		return c.Next()
+	}
+}
+
+func (policy Policy) matches(c *fiber.Ctx) bool {
+	if policy.Method != "" && !strings.EqualFold(policy.Method, c.Method()) {
+		return false
+	}
+	if policy.Host != "" && !matchHost(policy.Host, c.Hostname()) {
+		return false
+	}
+	if policy.Path != "" && !matchPath(policy.Path, c.Path()) {
+		return false
+	}
+	return true
+}
+
+func matchHost(pattern, host string) bool {
+	if suffix, found := strings.CutPrefix(pattern, "*."); found {
+		return len(host) > len(suffix)+1 && strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(suffix))
+	}
+	return strings.EqualFold(pattern, host)
+}
+
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if segment == "*" && i == len(patternSegments)-1 {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPolicyRouterKeepsPoliciesIndependent(t *testing.T) {
+	// both policies share one strategy instance; keys are still separated
+	shared := strategies.NewFixedWindowStrategy(1, time.Minute)
+	app := fiber.New()
+	app.Use(PolicyRateLimitingMiddleware([]Policy{
+		{Name: "login", Method: http.MethodPost, Path: "/login", Strategy: shared},
+		{Name: "search", Path: "/search/*", Strategy: shared},
+	}, func(*fiber.Ctx) string { return "client" }))
+	app.All("/*", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	expect := func(method, target string, want int) {
+		t.Helper()
+		req, _ := http.NewRequest(method, target, nil)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("%s %s: expected %d, got %d", method, target, want, resp.StatusCode)
+		}
+	}
+
+	expect(http.MethodPost, "/login", fiber.StatusOK)
+	expect(http.MethodPost, "/login", fiber.StatusTooManyRequests)
+	expect(http.MethodGet, "/search/books", fiber.StatusOK)
+	expect(http.MethodGet, "/search/films", fiber.StatusTooManyRequests)
+
+	// unmatched requests pass through untouched
+	expect(http.MethodGet, "/login", fiber.StatusOK)
+	expect(http.MethodGet, "/login", fiber.StatusOK)
+
+	if clients := shared.Clients(); len(clients) != 2 || clients[0] != "login:client" || clients[1] != "search:client" {
+		t.Fatalf("unexpected keys %v", clients)
+	}
+}
+
+func TestPolicyMatching(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/users/:id", "/users/42", true},
+		{"/users/:id", "/users/42/posts", false},
+		{"/users/:id", "/users", false},
+		{"/users/:id/*", "/users/42/posts/7", true},
+		{"/api/*", "/api", true},
+		{"/", "/", true},
+		{"/", "/x", false},
+	}
+	for _, tc := range cases {
+		if got := matchPath(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+
+	if !matchHost("*.example.com", "api.Example.com") || matchHost("*.example.com", "example.com") {
+		t.Error("unexpected wildcard host matching")
+	}
+}
+
+func TestPolicyRouterRejectsDuplicateNames(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for duplicate policy names")
+		}
+	}()
+	s := strategies.NewFixedWindowStrategy(1, time.Minute)
+	PolicyRateLimitingMiddleware([]Policy{{Name: "a", Strategy: s}, {Name: "a", Strategy: s}}, func(*fiber.Ctx) string { return "" })
+}
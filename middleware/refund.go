@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gofiber/fiber/v2"
+)
+
+type admissionsKey struct{}
+
+// Refund gives back the unit charged for the current request by every
+// rate limiting middleware that admitted it, e.g. after a cache hit.
+//
+// Parameters:
+//   - c: the request context.
+//
+// Returns:
+//   - bool: true if at least one unit was refunded.
+//
+// Refunds are skipped for strategies that do not implement strategies.Refunder
+// and a request is never refunded more than it was charged, including by
+// WithSkipFailedRequests or WithSkipSuccessfulRequests.
+func Refund(c *fiber.Ctx) bool {
+	admissions, _ := c.Locals(admissionsKey{}).([]*limiter.Admission)
+	refunded := false
+	for _, admission := range admissions {
+		if admission.Refund() {
+			refunded = true
+		}
+	}
+	return refunded
+}
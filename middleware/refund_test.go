@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRefundFromHandler(t *testing.T) {
+	strategy := strategies.NewFixedWindowStrategy(1, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, func(*fiber.Ctx) string { return "client" }))
+	app.Get("/cached", func(c *fiber.Ctx) error {
+		if !Refund(c) {
+			t.Error("expected refund to succeed")
+		}
+		if Refund(c) {
+			t.Error("a request must not be refunded twice")
+		}
+		return c.SendString("cached")
+	})
+	app.Get("/work", func(c *fiber.Ctx) error { return c.SendString("work") })
+
+	expectStatus(t, app, "/cached", fiber.StatusOK)
+	expectStatus(t, app, "/cached", fiber.StatusOK)
+	expectStatus(t, app, "/work", fiber.StatusOK)
+	expectStatus(t, app, "/work", fiber.StatusTooManyRequests)
+}
+
+func TestRefundIsNotRepeatedBySkipOptions(t *testing.T) {
+	strategy := strategies.NewTokenBucketStrategy(0, 2)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, func(*fiber.Ctx) string { return "client" }, WithSkipFailedRequests()))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		Refund(c)
+		return fiber.ErrBadRequest
+	})
+
+	// one admitted request is spent, then a failing one refunds itself once
+	expectStatus(t, app, "/", fiber.StatusOK)
+	expectStatus(t, app, "/fail", fiber.StatusBadRequest)
+	if state, _ := strategy.Inspect("client"); state.Tokens != 1 {
+		t.Fatalf("expected 1 token left, got %v", state.Tokens)
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"html/template"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/limiter"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the RFC 9457 problem details media type.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Rejection describes a rejected request to a Renderer.
+type Rejection struct {
+	// Status is the response status: 429, 403, or 503 when shedding load.
+	Status   int
+	Title    string
+	Detail   string
+	ClientId string
+	// RetryAfter is how long the client should wait; zero if unknown or
+	// immediately retryable.
+	RetryAfter time.Duration
+	// Limit is the client's configured capacity, or zero if the strategy does
+	// not implement strategies.Inspector.
+	Limit float64
+}
+
+// Renderer writes the response for a rejected request. The status code,
+// Retry-After and Vary headers are already set when it runs.
+type Renderer func(c *fiber.Ctx, rejection Rejection) error
+
+// ProblemDetails is the RFC 9457 body written for problem+json responses.
+type ProblemDetails struct {
+	Type       string  `json:"type"`
+	Title      string  `json:"title"`
+	Status     int     `json:"status"`
+	Detail     string  `json:"detail"`
+	RetryAfter int64   `json:"retryAfter,omitempty"`
+	Limit      float64 `json:"limit,omitempty"`
+}
+
+var defaultHTMLTemplate = template.Must(template.New("rejection").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Status}} {{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Detail}}</p>
+{{- if .RetryAfter}}
+<p>Please retry in {{.RetryAfter}}.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// TextRenderer writes the rejection detail as plain text. It is the default
+// when the client expresses no preference.
+func TextRenderer(c *fiber.Ctx, rejection Rejection) error {
+	return c.SendString(rejection.Detail)
+}
+
+// ProblemRenderer writes an RFC 9457 problem details document with
+// retryAfter (in seconds) and limit extension members.
+func ProblemRenderer(c *fiber.Ctx, rejection Rejection) error {
+	body, err := c.App().Config().JSONEncoder(ProblemDetails{
+		Type:       "about:blank",
+		Title:      rejection.Title,
+		Status:     rejection.Status,
+		Detail:     rejection.Detail,
+		RetryAfter: limiter.RetryAfterSeconds(rejection.RetryAfter),
+		Limit:      rejection.Limit,
+	})
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.Send(body)
+}
+
+// HTMLRenderer renders rejections with tmpl, which receives the Rejection.
+func HTMLRenderer(tmpl *template.Template) Renderer {
+	return func(c *fiber.Ctx, rejection Rejection) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return tmpl.Execute(c.Response().BodyWriter(), rejection)
+	}
+}
+
+// render negotiates the response format from Accept and writes the rejection.
+func (cfg *config) render(c *fiber.Ctx, rejection Rejection) error {
+	if value := limiter.RetryAfterHeader(rejection.RetryAfter); value != "" {
+		c.Set(fiber.HeaderRetryAfter, value)
+	}
+	c.Vary(fiber.HeaderAccept)
+	c.Status(rejection.Status)
+
+	renderer := cfg.renderers[c.Accepts(cfg.mediaTypes...)]
+	if renderer == nil {
+		renderer = cfg.renderers[cfg.mediaTypes[0]]
+	}
+	return renderer(c, rejection)
+}
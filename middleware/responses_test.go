@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gofiber/fiber/v2"
+)
+
+func rejectedResponse(t *testing.T, accept string, opts ...Option) (*http.Response, string) {
+	t.Helper()
+	strategy := strategies.NewFixedWindowStrategy(0, time.Minute)
+	app := fiber.New()
+	app.Use(RateLimitingMiddleware(strategy, func(*fiber.Ctx) string { return "client" }, opts...))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set(fiber.HeaderAccept, accept)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestRejectionDefaultsToPlainText(t *testing.T) {
+	resp, body := rejectedResponse(t, "")
+	if !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), fiber.MIMETextPlain) || body != "Rate limit exceeded." {
+		t.Fatalf("unexpected response %q: %q", resp.Header.Get(fiber.HeaderContentType), body)
+	}
+	if resp.Header.Get(fiber.HeaderVary) != fiber.HeaderAccept {
+		t.Fatalf("expected Vary: Accept, got %q", resp.Header.Get(fiber.HeaderVary))
+	}
+}
+
+func TestRejectionNegotiatesProblemJSON(t *testing.T) {
+	resp, body := rejectedResponse(t, "application/json")
+	if resp.Header.Get(fiber.HeaderContentType) != MIMEApplicationProblemJSON {
+		t.Fatalf("expected problem+json, got %q", resp.Header.Get(fiber.HeaderContentType))
+	}
+
+	var problem ProblemDetails
+	if err := json.Unmarshal([]byte(body), &problem); err != nil {
+		t.Fatalf("invalid problem body %q: %v", body, err)
+	}
+	if problem.Status != fiber.StatusTooManyRequests || problem.Title != "Too Many Requests" || problem.RetryAfter != 60 || problem.Type != "about:blank" {
+		t.Fatalf("unexpected problem %+v", problem)
+	}
+}
+
+func TestRejectionNegotiatesHTML(t *testing.T) {
+	resp, body := rejectedResponse(t, "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	if !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), fiber.MIMETextHTML) || !strings.Contains(body, "<h1>Too Many Requests</h1>") {
+		t.Fatalf("unexpected html response %q", body)
+	}
+}
+
+func TestRejectionUsesCustomRenderer(t *testing.T) {
+	custom := func(c *fiber.Ctx, rejection Rejection) error {
+		return c.SendString("custom " + rejection.ClientId)
+	}
+	_, body := rejectedResponse(t, "", WithRenderer(fiber.MIMETextPlain, custom))
+	if body != "custom client" {
+		t.Fatalf("expected custom body, got %q", body)
+	}
+}
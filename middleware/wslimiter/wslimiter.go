@@ -0,0 +1,165 @@
+// Package wslimiter rate limits the messages received on WebSocket and other
+// message-oriented connections. The HTTP middleware only sees the upgrade
+// request; wrap the connection afterwards to apply a strategy per connection
+// or, by sharing a key, per user.
+package wslimiter
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+// Message types and close codes from RFC 6455.
+const (
+	CloseMessage         = 8
+	ClosePolicyViolation = 1008
+)
+
+// ErrRateLimited is returned by ReadMessage after a connection is closed by
+// the Close action, or by the Delay action for a message that cannot be
+// admitted within MaxDelay.
+var ErrRateLimited = errors.New("wslimiter: connection closed for exceeding the message rate limit")
+
+// Conn is the subset of a WebSocket connection the limiter needs. Connections
+// from github.com/gofiber/contrib/websocket, github.com/fasthttp/websocket and
+// github.com/gorilla/websocket satisfy it.
+type Conn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	Close() error
+}
+
+// Action is what happens to a message received over the limit.
+type Action int
+
+const (
+	// Drop discards the message and reads the next one.
+	Drop Action = iota
+	// Delay holds the message until the strategy admits it. The connection is
+	// not read in the meantime, which pushes back on the sender. A message
+	// the strategy will not admit within MaxDelay, or ever, is answered as by
+	// Close.
+	Delay
+	// Close sends a policy violation (1008) close frame and closes the
+	// connection.
+	Close
+)
+
+// MessageLimiter wraps a connection and applies a strategy to inbound messages.
+type MessageLimiter struct {
+	Conn     Conn
+	Strategy strategies.RateLimitStrategy
+	Key      string
+	Action   Action
+	// OnLimited, if set, is called for every message over the limit, before
+	// the action is applied.
+	OnLimited func(key string, action Action)
+	// MaxDelay bounds how long the Delay action holds a message; zero means
+	// no bound.
+	MaxDelay time.Duration
+	// Sleep, if set, replaces time.Sleep for the Delay action.
+	Sleep func(time.Duration)
+}
+
+// DefaultMaxDelay is the MaxDelay set by NewMessageLimiter.
+const DefaultMaxDelay = time.Minute
+
+// NewMessageLimiter wraps conn so that ReadMessage applies strategy to every
+// message received.
+//
+// Parameters:
+//   - conn: the upgraded connection.
+//   - strategy: RateLimitStrategy that defines how many messages are allowed.
+//   - key: the strategy key, e.g. a connection id, or a user id to share the
+//     limit across all of a user's connections.
+//   - action: Drop, Delay or Close.
+//
+// Returns:
+//   - *MessageLimiter: a limiter whose ReadMessage replaces conn.ReadMessage.
+//
+// MaxDelay defaults to DefaultMaxDelay.
+func NewMessageLimiter(conn Conn, strategy strategies.RateLimitStrategy, key string, action Action) *MessageLimiter {
+	return &MessageLimiter{
+		Conn:     conn,
+		Strategy: strategy,
+		Key:      key,
+		Action:   action,
+		MaxDelay: DefaultMaxDelay,
+	}
+}
+
+// ReadMessage returns the next message the strategy admits. Control frames
+// are handled by the underlying connection and never counted.
+func (limiter *MessageLimiter) ReadMessage() (int, []byte, error) {
+	for {
+		messageType, p, err := limiter.Conn.ReadMessage()
+		if err != nil || limiter.Strategy.IsRequestAllowed(limiter.Key) {
+			return messageType, p, err
+		}
+		if limiter.OnLimited != nil {
+			limiter.OnLimited(limiter.Key, limiter.Action)
+		}
+
+		switch limiter.Action {
+		case Delay:
+			if limiter.wait() {
+				return messageType, p, nil
+			}
+			limiter.close()
+			return 0, nil, ErrRateLimited
+		case Close:
+			limiter.close()
+			return 0, nil, ErrRateLimited
+		}
+	}
+}
+
+// wait sleeps until the strategy admits a message. It returns false without
+// waiting further once admission would take longer than MaxDelay, or if the
+// strategy keeps denying while reporting no wait, e.g. a token bucket that
+// does not refill.
+func (limiter *MessageLimiter) wait() bool {
+	sleep := limiter.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	var waited time.Duration
+	stalled := false
+	for {
+		wait := limiter.Strategy.RetryAfter(limiter.Key)
+		if wait <= 0 {
+			// A strategy may report zero just before it admits again, so
+			// back off once before giving up.
+			if stalled {
+				return false
+			}
+			stalled = true
+			wait = 10 * time.Millisecond
+		} else {
+			stalled = false
+		}
+		if limiter.MaxDelay > 0 && waited+wait > limiter.MaxDelay {
+			return false
+		}
+
+		sleep(wait)
+		waited += wait
+		if limiter.Strategy.IsRequestAllowed(limiter.Key) {
+			return true
+		}
+	}
+}
+
+func (limiter *MessageLimiter) close() {
+	reason := "rate limit exceeded"
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, ClosePolicyViolation)
+	payload = append(payload, reason...)
+
+	_ = limiter.Conn.WriteControl(CloseMessage, payload, time.Now().Add(time.Second))
+	_ = limiter.Conn.Close()
+}
@@ -0,0 +1,142 @@
+package wslimiter
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+)
+
+// fakeConn yields queued text messages and records control frames.
+type fakeConn struct {
+	messages []string
+	control  [][]byte
+	closed   bool
+}
+
+func (conn *fakeConn) ReadMessage() (int, []byte, error) {
+	if len(conn.messages) == 0 {
+		return 0, nil, io.EOF
+	}
+	message := conn.messages[0]
+	conn.messages = conn.messages[1:]
+	return 1, []byte(message), nil
+}
+
+func (conn *fakeConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType == CloseMessage {
+		conn.control = append(conn.control, data)
+	}
+	return nil
+}
+
+func (conn *fakeConn) Close() error {
+	conn.closed = true
+	return nil
+}
+
+func newLimiter(action Action, messages ...string) (*MessageLimiter, *fakeConn, *strategytest.FakeClock) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewTokenBucketStrategy(1, 2)
+	strategy.Clock = clock
+	conn := &fakeConn{messages: messages}
+	limiter := NewMessageLimiter(conn, strategy, "user-1", action)
+	limiter.Sleep = clock.Advance
+	return limiter, conn, clock
+}
+
+func readAll(limiter *MessageLimiter) ([]string, error) {
+	var read []string
+	for {
+		_, p, err := limiter.ReadMessage()
+		if err != nil {
+			return read, err
+		}
+		read = append(read, string(p))
+	}
+}
+
+func TestDropDiscardsMessagesOverTheLimit(t *testing.T) {
+	limiter, _, _ := newLimiter(Drop, "a", "b", "c", "d")
+	dropped := 0
+	limiter.OnLimited = func(key string, action Action) { dropped++ }
+
+	read, err := readAll(limiter)
+	if err != io.EOF {
+		t.Fatalf("expected the connection's EOF, got %v", err)
+	}
+	if len(read) != 2 || read[0] != "a" || read[1] != "b" || dropped != 2 {
+		t.Fatalf("expected a and b with 2 dropped, got %v with %d dropped", read, dropped)
+	}
+}
+
+func TestDelayHoldsMessagesUntilAdmitted(t *testing.T) {
+	limiter, _, clock := newLimiter(Delay, "a", "b", "c", "d")
+	start := clock.Now()
+
+	read, _ := readAll(limiter)
+	if len(read) != 4 {
+		t.Fatalf("expected every message to be delivered, got %v", read)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 2*time.Second {
+		t.Fatalf("expected 2 messages to wait a second each, waited %s", elapsed)
+	}
+}
+
+func TestCloseSendsPolicyViolation(t *testing.T) {
+	limiter, conn, _ := newLimiter(Close, "a", "b", "c", "d")
+
+	read, err := readAll(limiter)
+	if err != ErrRateLimited {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if len(read) != 2 || !conn.closed || len(conn.control) != 1 {
+		t.Fatalf("expected 2 messages and a close, got %v closed=%v", read, conn.closed)
+	}
+	if code := binary.BigEndian.Uint16(conn.control[0]); code != ClosePolicyViolation {
+		t.Fatalf("expected close code 1008, got %d", code)
+	}
+}
+
+// A delayed message the strategy never admits must not hold the connection
+// forever.
+func TestDelayClosesWhenNeverAdmitted(t *testing.T) {
+	cases := map[string]strategies.RateLimitStrategy{
+		"no refill":    strategies.NewTokenBucketStrategy(0, 1),
+		"zero limit":   strategies.NewFixedWindowStrategy(0, time.Second),
+		"ban too long": strategies.NewPenaltyBoxStrategy(strategies.NewFixedWindowStrategy(1, time.Second), 1, time.Minute, time.Hour, time.Hour),
+	}
+	for name, strategy := range cases {
+		clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+		setClock(strategy, clock)
+		conn := &fakeConn{messages: []string{"a", "b", "c"}}
+		limiter := NewMessageLimiter(conn, strategy, "user-1", Delay)
+		limiter.Sleep = clock.Advance
+		start := clock.Now()
+
+		if _, err := readAll(limiter); err != ErrRateLimited {
+			t.Fatalf("%s: expected ErrRateLimited, got %v", name, err)
+		}
+		if !conn.closed || len(conn.control) != 1 {
+			t.Fatalf("%s: expected the connection to be closed with a close frame", name)
+		}
+		if elapsed := clock.Now().Sub(start); elapsed > DefaultMaxDelay {
+			t.Fatalf("%s: expected to give up within MaxDelay, waited %s", name, elapsed)
+		}
+	}
+}
+
+func setClock(strategy strategies.RateLimitStrategy, clock strategies.Clock) {
+	switch s := strategy.(type) {
+	case *strategies.TokenBucketStrategy:
+		s.Clock = clock
+	case *strategies.FixedWindowStrategy:
+		s.Clock = clock
+	case *strategies.PenaltyBoxStrategy:
+		s.Clock = clock
+		setClock(s.Strategy, clock)
+	}
+}
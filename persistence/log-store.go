@@ -0,0 +1,241 @@
+package persistence
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// Store saves named blobs, such as strategy snapshots.
+type Store interface {
+	// Save durably stores data under name, replacing any previous value.
+	Save(name string, data []byte) error
+	// Load returns the latest data stored under name and whether it exists.
+	Load(name string) ([]byte, bool, error)
+}
+
+// recordHeaderSize covers the name length, data length and checksum fields.
+const recordHeaderSize = 12
+
+// maxRecordSize bounds a single record so a corrupt length cannot trigger a
+// huge allocation.
+const maxRecordSize = 1 << 30
+
+type LogStore struct {
+	// CompactionThreshold is the file size in bytes below which the log is
+	// never compacted.
+	CompactionThreshold int64
+	path                string
+	file                *os.File
+	values              map[string][]byte
+	size                int64
+	liveSize            int64
+	mutex               sync.Mutex
+}
+
+// OpenLogStore opens or creates an append-only log of named snapshots.
+//
+// Parameters:
+//   - path: the log file location.
+//
+// Returns:
+//   - *LogStore: the opened store with the latest value of every name loaded.
+//   - error: if the file cannot be opened or read.
+//
+// Every Save appends a checksummed record and syncs it to disk. A torn record
+// left by a crash is truncated on open. Once the log exceeds
+// CompactionThreshold (1 MiB by default) and is mostly superseded records, it
+// is rewritten with only the latest value of each name.
+func OpenLogStore(path string) (*LogStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &LogStore{
+		CompactionThreshold: 1 << 20,
+		path:                path,
+		file:                file,
+		values:              make(map[string][]byte),
+	}
+	if err := store.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Save appends data under name and compacts the log if needed.
+func (store *LogStore) Save(name string, data []byte) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.file == nil {
+		return os.ErrClosed
+	}
+
+	record := encodeRecord(name, data)
+	if _, err := store.file.Write(record); err != nil {
+		return err
+	}
+	if err := store.file.Sync(); err != nil {
+		return err
+	}
+
+	if previous, exists := store.values[name]; exists {
+		store.liveSize -= int64(recordHeaderSize + len(name) + len(previous))
+	}
+	store.values[name] = append([]byte(nil), data...)
+	store.size += int64(len(record))
+	store.liveSize += int64(len(record))
+
+	if store.size > store.CompactionThreshold && store.size > 2*store.liveSize {
+		return store.compact()
+	}
+	return nil
+}
+
+// Load returns the latest data saved under name.
+func (store *LogStore) Load(name string) ([]byte, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.file == nil {
+		return nil, false, os.ErrClosed
+	}
+	data, exists := store.values[name]
+	return append([]byte(nil), data...), exists, nil
+}
+
+// Compact rewrites the log with only the latest value of each name.
+func (store *LogStore) Compact() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.file == nil {
+		return os.ErrClosed
+	}
+	return store.compact()
+}
+
+// Close closes the underlying file.
+func (store *LogStore) Close() error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.file == nil {
+		return nil
+	}
+	err := store.file.Close()
+	store.file = nil
+	return err
+}
+
+// compact must be called with the mutex held.
+func (store *LogStore) compact() error {
+	tmpPath := store.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	writer := bufio.NewWriter(tmp)
+	for name, data := range store.values {
+		record := encodeRecord(name, data)
+		if _, err := writer.Write(record); err != nil {
+			tmp.Close()
+			return err
+		}
+		size += int64(len(record))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, store.path); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	store.file.Close()
+	store.file = tmp
+	store.size = size
+	store.liveSize = size
+	return nil
+}
+
+// load must be called with the mutex held.
+func (store *LogStore) load() error {
+	reader := bufio.NewReader(store.file)
+	var offset int64
+
+	for {
+		name, data, n, err := decodeRecord(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// a torn or corrupt tail: keep everything before it
+			if err := store.file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+
+		if previous, exists := store.values[name]; exists {
+			store.liveSize -= int64(recordHeaderSize + len(name) + len(previous))
+		}
+		store.values[name] = data
+		store.liveSize += n
+		offset += n
+	}
+
+	store.size = offset
+	_, err := store.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func encodeRecord(name string, data []byte) []byte {
+	record := make([]byte, recordHeaderSize+len(name)+len(data))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(name)))
+	binary.BigEndian.PutUint32(record[4:8], uint32(len(data)))
+	copy(record[recordHeaderSize:], name)
+	copy(record[recordHeaderSize+len(name):], data)
+	binary.BigEndian.PutUint32(record[8:12], crc32.ChecksumIEEE(record[recordHeaderSize:]))
+	return record
+}
+
+func decodeRecord(reader io.Reader) (string, []byte, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return "", nil, 0, fmt.Errorf("torn record header: %w", err)
+		}
+		return "", nil, 0, err
+	}
+
+	nameLen := binary.BigEndian.Uint32(header[0:4])
+	dataLen := binary.BigEndian.Uint32(header[4:8])
+	if uint64(nameLen)+uint64(dataLen) > maxRecordSize {
+		return "", nil, 0, errors.New("record too large")
+	}
+
+	body := make([]byte, nameLen+dataLen)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return "", nil, 0, fmt.Errorf("torn record body: %w", io.ErrUnexpectedEOF)
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[8:12]) {
+		return "", nil, 0, errors.New("record checksum mismatch")
+	}
+	return string(body[:nameLen]), body[nameLen:], int64(recordHeaderSize + len(body)), nil
+}
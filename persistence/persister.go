@@ -0,0 +1,112 @@
+package persistence
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+type Persister struct {
+	Store    Store
+	Name     string
+	Strategy strategies.Snapshotter
+	Interval time.Duration
+	// OnError, if set, receives errors from periodic saves.
+	OnError func(error)
+	stop    chan struct{}
+	done    chan struct{}
+	mutex   sync.Mutex
+}
+
+// NewPersister creates a Persister that keeps a strategy's state in a store.
+//
+// Parameters:
+//   - store: where snapshots are saved, e.g. a *LogStore.
+//   - name: the key of the strategy's snapshot; use one name per strategy.
+//   - strategy: the strategy to persist.
+//   - interval: how often Start saves a snapshot; must be positive.
+//
+// Returns:
+//   - *Persister: a pointer to a new persister.
+//
+// Call Restore before serving traffic, Start to save periodically and Stop on
+// shutdown to save a final snapshot. The strategy's algorithm is unchanged;
+// anything admitted after the last save is lost on a crash.
+func NewPersister(store Store, name string, strategy strategies.Snapshotter, interval time.Duration) *Persister {
+	return &Persister{
+		Store:    store,
+		Name:     name,
+		Strategy: strategy,
+		Interval: interval,
+	}
+}
+
+// Restore loads the latest snapshot into the strategy. It is a no-op if none
+// has been saved yet.
+func (persister *Persister) Restore() error {
+	data, exists, err := persister.Store.Load(persister.Name)
+	if err != nil || !exists {
+		return err
+	}
+	return persister.Strategy.Restore(bytes.NewReader(data))
+}
+
+// Save snapshots the strategy into the store.
+func (persister *Persister) Save() error {
+	var buf bytes.Buffer
+	if err := persister.Strategy.Snapshot(&buf); err != nil {
+		return err
+	}
+	return persister.Store.Save(persister.Name, buf.Bytes())
+}
+
+// Start saves a snapshot every Interval until Stop is called. It returns an
+// error if Interval is not positive.
+func (persister *Persister) Start() error {
+	if persister.Interval <= 0 {
+		return fmt.Errorf("persister interval must be positive, got %s", persister.Interval)
+	}
+	persister.mutex.Lock()
+	defer persister.mutex.Unlock()
+
+	if persister.stop != nil {
+		return nil
+	}
+	persister.stop = make(chan struct{})
+	persister.done = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(persister.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := persister.Save(); err != nil && persister.OnError != nil {
+					persister.OnError(err)
+				}
+			}
+		}
+	}(persister.stop, persister.done)
+	return nil
+}
+
+// Stop ends periodic saving and saves a final snapshot.
+func (persister *Persister) Stop() error {
+	persister.mutex.Lock()
+	if persister.stop != nil {
+		close(persister.stop)
+		<-persister.done
+		persister.stop = nil
+		persister.done = nil
+	}
+	persister.mutex.Unlock()
+
+	return persister.Save()
+}
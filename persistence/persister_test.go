@@ -0,0 +1,79 @@
+package persistence
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+func TestPersisterSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limiter.log")
+
+	store, _ := OpenLogStore(path)
+	quota := strategies.NewFixedWindowStrategy(2, 24*time.Hour)
+	persister := NewPersister(store, "daily", quota, time.Hour)
+	if err := persister.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	_ = quota.IsRequestAllowed("userA")
+	_ = quota.IsRequestAllowed("userA")
+	if err := persister.Stop(); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	store.Close()
+
+	// a fresh process restores the exhausted quota
+	store, _ = OpenLogStore(path)
+	defer store.Close()
+	restarted := strategies.NewFixedWindowStrategy(2, 24*time.Hour)
+	if err := NewPersister(store, "daily", restarted, time.Hour).Restore(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if restarted.IsRequestAllowed("userA") {
+		t.Fatal("quota should survive the restart")
+	}
+}
+
+func TestPersisterSavesPeriodically(t *testing.T) {
+	store, _ := OpenLogStore(filepath.Join(t.TempDir(), "limiter.log"))
+	defer store.Close()
+
+	bucket := strategies.NewTokenBucketStrategy(0, 1)
+	_ = bucket.IsRequestAllowed("userA")
+	persister := NewPersister(store, "bucket", bucket, 10*time.Millisecond)
+	if err := persister.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if _, exists, _ := store.Load("bucket"); !exists {
+		t.Fatal("expected a periodic snapshot")
+	}
+	_ = persister.Stop()
+}
+
+func TestPersisterRestoreWithoutSnapshot(t *testing.T) {
+	store, _ := OpenLogStore(filepath.Join(t.TempDir(), "limiter.log"))
+	defer store.Close()
+
+	if err := NewPersister(store, "none", strategies.NewTokenBucketStrategy(1, 1), time.Hour).Restore(); err != nil {
+		t.Fatalf("expected no error without snapshot, got %v", err)
+	}
+}
+
+func TestPersisterStartRejectsNonPositiveInterval(t *testing.T) {
+	store, _ := OpenLogStore(filepath.Join(t.TempDir(), "limiter.log"))
+	defer store.Close()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		persister := NewPersister(store, "bucket", strategies.NewTokenBucketStrategy(1, 1), interval)
+		if err := persister.Start(); err == nil {
+			t.Fatalf("expected an error for interval %s", interval)
+		}
+		if err := persister.Stop(); err != nil {
+			t.Fatalf("expected stop after a failed start to save, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request is a single recorded request.
+type Request struct {
+	Time   time.Time
+	IP     string
+	Method string
+	// Path is the request target, including any query string.
+	Path    string
+	Headers map[string]string
+	// Status is the recorded response status, or zero if unknown.
+	Status int
+}
+
+// Format names a supported log format.
+type Format string
+
+const (
+	// Common is the Apache/NCSA common log format.
+	Common Format = "common"
+	// Combined is the common log format followed by the quoted referer and
+	// user agent.
+	Combined Format = "combined"
+	// JSONLines holds one JSON object per line with the fields time (RFC 3339),
+	// ip, method, path, headers and status.
+	JSONLines Format = "jsonl"
+)
+
+// commonLogPattern matches common log lines and, optionally, the combined
+// referer and user agent fields.
+var commonLogPattern = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "(\S+) (\S+)(?: [^"]*)?" (\d{3}|-) \S+(?: "([^"]*)" "([^"]*)")?`)
+
+// commonLogTime is the timestamp layout of common log lines.
+const commonLogTime = "02/Jan/2006:15:04:05 -0700"
+
+type jsonRequest struct {
+	Time    time.Time         `json:"time"`
+	IP      string            `json:"ip"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Status  int               `json:"status"`
+}
+
+// Parse reads all requests from r in the given format.
+//
+// Parameters:
+//   - r: the log contents.
+//   - format: Common, Combined or JSONLines.
+//
+// Returns:
+//   - []Request: the requests, sorted by time; requests with equal times keep
+//     their order in the log.
+//   - error: the first malformed line, reported with its line number.
+//
+// Blank lines are skipped. Combined logs populate the Referer and User-Agent
+// headers, and an authenticated user other than "-" becomes the X-Remote-User
+// header so resolvers can key on it.
+func Parse(r io.Reader, format Format) ([]Request, error) {
+	var parseLine func(string) (Request, error)
+	switch format {
+	case Common, Combined:
+		parseLine = parseCommonLine
+	case JSONLines:
+		parseLine = parseJSONLine
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	var requests []Request
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		request, err := parseLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		requests = append(requests, request)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].Time.Before(requests[j].Time) })
+	return requests, nil
+}
+
+func parseCommonLine(line string) (Request, error) {
+	match := commonLogPattern.FindStringSubmatch(line)
+	if match == nil {
+		return Request{}, fmt.Errorf("not a common log line")
+	}
+
+	at, err := time.Parse(commonLogTime, match[3])
+	if err != nil {
+		return Request{}, err
+	}
+
+	request := Request{
+		Time:    at,
+		IP:      match[1],
+		Method:  match[4],
+		Path:    match[5],
+		Headers: make(map[string]string),
+	}
+	if match[6] != "-" {
+		request.Status, _ = strconv.Atoi(match[6])
+	}
+	if match[2] != "-" {
+		request.Headers["X-Remote-User"] = match[2]
+	}
+	if match[7] != "" && match[7] != "-" {
+		request.Headers["Referer"] = match[7]
+	}
+	if match[8] != "" && match[8] != "-" {
+		request.Headers["User-Agent"] = match[8]
+	}
+	return request, nil
+}
+
+func parseJSONLine(line string) (Request, error) {
+	var decoded jsonRequest
+	if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+		return Request{}, err
+	}
+	if decoded.Time.IsZero() {
+		return Request{}, fmt.Errorf("missing time")
+	}
+	if decoded.Method == "" {
+		decoded.Method = "GET"
+	}
+	if decoded.Path == "" {
+		decoded.Path = "/"
+	}
+	if decoded.Headers == nil {
+		decoded.Headers = make(map[string]string)
+	}
+	return Request(decoded), nil
+}
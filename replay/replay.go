@@ -0,0 +1,93 @@
+// Package replay parses recorded traffic and replays it through Fiber
+// handlers, so rate limiting policies can be previewed and regression tested
+// against real request logs on a virtual clock.
+package replay
+
+import (
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// RequestCtx builds a fasthttp request context for the request, with the
+// recorded IP as the remote address. Requests whose IP is not a literal
+// address, such as a logged hostname, get the unspecified address.
+func (request Request) RequestCtx() *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.Header.SetMethod(request.Method)
+	req.SetRequestURI(request.Path)
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+	if len(req.Header.Host()) == 0 {
+		req.Header.SetHost("replay.local")
+	}
+
+	ip := net.ParseIP(request.IP)
+	if ip == nil {
+		ip = net.IPv4zero
+	}
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&req, &net.TCPAddr{IP: ip}, nil)
+	return ctx
+}
+
+// Keys resolves the client key of each request with the same resolvers the
+// middleware uses.
+//
+// Parameters:
+//   - requests: the requests to resolve.
+//   - resolver: a client ID resolver, e.g. one built from the resolvers package.
+//
+// Returns:
+//   - []string: the key of each request, in order.
+//
+// The resolver runs in an app.Use handler, so resolvers depending on route
+// parameters or patterns see no route. Use Run with the real app for those.
+func Keys(requests []Request, resolver func(*fiber.Ctx) string) []string {
+	keys := make([]string, len(requests))
+	index := 0
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		keys[index] = resolver(c)
+		return nil
+	})
+
+	handler := app.Handler()
+	for i, request := range requests {
+		index = i
+		handler(request.RequestCtx())
+	}
+	return keys
+}
+
+// Clock is a virtual clock that Run can move, such as
+// strategytest.FakeClock.
+type Clock interface {
+	Set(t time.Time)
+}
+
+// Run replays requests through app, moving clock to each request's time
+// before serving it. Replays are deterministic as long as the app's
+// strategies read clock and the requests are sorted by time.
+//
+// Parameters:
+//   - app: the app to serve the requests, typically one using
+//     RateLimitingMiddleware.
+//   - clock: the clock the app's strategies read, e.g. a
+//     strategytest.FakeClock.
+//   - requests: the requests to replay, sorted by time.
+//   - observe: called with each request and its response status; may be nil.
+func Run(app *fiber.App, clock Clock, requests []Request, observe func(Request, int)) {
+	handler := app.Handler()
+	for _, request := range requests {
+		clock.Set(request.Time)
+		ctx := request.RequestCtx()
+		handler(ctx)
+		if observe != nil {
+			observe(request, ctx.Response.StatusCode())
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package replay
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/middleware"
+	"github.com/gabisonia/fiber-rate-limiter/resolvers"
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+	"github.com/gofiber/fiber/v2"
+)
+
+const combinedLog = `10.0.0.2 - - [01/Jan/2030:00:00:01 +0000] "GET /b HTTP/1.1" 200 12 "-" "curl/8.0"
+10.0.0.1 - alice [01/Jan/2030:00:00:00 +0000] "POST /a?x=1 HTTP/1.1" 429 - "https://example.com/" "Mozilla/5.0"
+
+10.0.0.1 - - [01/Jan/2030:01:00:00 +0100] "GET / HTTP/1.0" 200 5
+`
+
+func TestParseCombined(t *testing.T) {
+	requests, err := Parse(strings.NewReader(combinedLog), Combined)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(requests))
+	}
+
+	first := requests[0]
+	if first.IP != "10.0.0.1" || first.Method != "POST" || first.Path != "/a?x=1" || first.Status != 429 {
+		t.Fatalf("unexpected first request: %+v", first)
+	}
+	if first.Headers["X-Remote-User"] != "alice" || first.Headers["User-Agent"] != "Mozilla/5.0" || first.Headers["Referer"] != "https://example.com/" {
+		t.Fatalf("unexpected headers: %v", first.Headers)
+	}
+
+	// 01:00:00 +0100 is the same instant as the first request and sorts after it.
+	if !requests[1].Time.Equal(first.Time) || requests[1].Path != "/" {
+		t.Fatalf("expected equal timestamps to keep log order, got %+v", requests[1])
+	}
+	if requests[2].Headers["Referer"] != "" || requests[2].Headers["User-Agent"] != "curl/8.0" {
+		t.Fatalf("unexpected headers: %v", requests[2].Headers)
+	}
+}
+
+func TestParseJSONLines(t *testing.T) {
+	log := `{"time":"2030-01-01T00:00:01Z","ip":"::1","method":"DELETE","path":"/x","headers":{"X-API-Key":"k1"},"status":204}
+{"time":"2030-01-01T00:00:00Z","ip":"10.0.0.1"}`
+
+	requests, err := Parse(strings.NewReader(log), JSONLines)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].Method != "GET" || requests[0].Path != "/" {
+		t.Fatalf("expected defaults for missing fields, got %+v", requests[0])
+	}
+	if requests[1].Headers["X-API-Key"] != "k1" || requests[1].Status != 204 {
+		t.Fatalf("unexpected second request: %+v", requests[1])
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		format Format
+		log    string
+	}{
+		{Common, "not a log line"},
+		{Common, `10.0.0.1 - - [yesterday] "GET / HTTP/1.1" 200 5`},
+		{JSONLines, `{"ip":"10.0.0.1"}`},
+		{JSONLines, `{`},
+		{"xml", ""},
+	}
+	for _, tc := range cases {
+		if _, err := Parse(strings.NewReader(tc.log), tc.format); err == nil {
+			t.Fatalf("expected %s log %q to fail", tc.format, tc.log)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	requests := []Request{
+		{IP: "10.0.0.1", Method: "GET", Path: "/", Headers: map[string]string{"X-API-Key": "k1"}},
+		{IP: "10.0.0.2", Method: "GET", Path: "/"},
+		{IP: "example.com", Method: "GET", Path: "/"},
+	}
+	resolver := resolvers.FirstOf(resolvers.Prefix("key", resolvers.Header("X-API-Key")), resolvers.Prefix("ip", resolvers.IP()))
+
+	keys := Keys(requests, resolver)
+	expected := []string{"key:k1", "ip:10.0.0.2", "ip:0.0.0.0"}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Fatalf("request %d: expected key %q, got %q", i, expected[i], keys[i])
+		}
+	}
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	start := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var requests []Request
+	for i := 0; i < 6; i++ {
+		requests = append(requests, Request{Time: start.Add(time.Duration(i) * 300 * time.Millisecond), IP: "10.0.0.1", Method: "GET", Path: "/"})
+	}
+
+	replay := func() []int {
+		clock := strategytest.NewFakeClock(start)
+		strategy := strategies.NewFixedWindowStrategy(2, time.Second)
+		strategy.Clock = clock
+
+		app := fiber.New()
+		app.Use(middleware.RateLimitingMiddleware(strategy, resolvers.IP()))
+		app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+		var statuses []int
+		Run(app, clock, requests, func(_ Request, status int) {
+			statuses = append(statuses, status)
+		})
+		return statuses
+	}
+
+	// The window opens at 0s and reopens at 1.2s: two admissions per window.
+	expected := []int{200, 200, 429, 429, 200, 200}
+	for attempt := 0; attempt < 2; attempt++ {
+		statuses := replay()
+		for i := range expected {
+			if statuses[i] != expected[i] {
+				t.Fatalf("attempt %d: expected statuses %v, got %v", attempt, expected, statuses)
+			}
+		}
+	}
+}
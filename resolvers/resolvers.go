@@ -0,0 +1,163 @@
+package resolvers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Resolver extracts a client key from a request. An empty string means the
+// resolver could not produce a key, which lets FirstOf fall through to the
+// next candidate. A Resolver can be passed wherever the middleware expects a
+// client ID resolver.
+type Resolver func(*fiber.Ctx) string
+
+// IP resolves the client key to c.IP().
+func IP() Resolver {
+	return func(c *fiber.Ctx) string {
+		return c.IP()
+	}
+}
+
+// Header resolves the client key to the value of the named request header,
+// e.g. Header("X-API-Key").
+func Header(name string) Resolver {
+	return func(c *fiber.Ctx) string {
+		return strings.TrimSpace(c.Get(name))
+	}
+}
+
+// Param resolves the client key to the named route parameter, e.g. Param("tenant")
+// for a route registered as "/tenants/:tenant/*".
+func Param(name string) Resolver {
+	return func(c *fiber.Ctx) string {
+		return c.Params(name)
+	}
+}
+
+// Route resolves the client key to the request method and matched route
+// pattern, e.g. "GET /users/:id". Register the middleware on the route itself;
+// under app.Use the pattern is that of the Use call.
+func Route() Resolver {
+	return func(c *fiber.Ctx) string {
+		return c.Method() + " " + c.Route().Path
+	}
+}
+
+// Locals resolves the client key to a value stored in c.Locals, typically the
+// authenticated user placed there by an auth middleware. Strings and
+// fmt.Stringers are used as is; other values are formatted with fmt.Sprint.
+func Locals(key any) Resolver {
+	return func(c *fiber.Ctx) string {
+		switch value := c.Locals(key).(type) {
+		case nil:
+			return ""
+		case string:
+			return value
+		case fmt.Stringer:
+			return value.String()
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+}
+
+// JWTClaim resolves the client key to a top-level claim of the bearer token in
+// the Authorization header, e.g. JWTClaim("sub").
+//
+// The token signature is NOT verified; only use this behind a middleware that
+// rejects invalid tokens, otherwise callers can pick their own key.
+func JWTClaim(claim string) Resolver {
+	return func(c *fiber.Ctx) string {
+		auth := c.Get(fiber.HeaderAuthorization)
+		if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+			return ""
+		}
+
+		parts := strings.Split(strings.TrimSpace(auth[7:]), ".")
+		if len(parts) != 3 {
+			return ""
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+		if err != nil {
+			return ""
+		}
+
+		var claims map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &claims); err != nil {
+			return ""
+		}
+		raw, found := claims[claim]
+		if !found {
+			return ""
+		}
+
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			return text
+		}
+		var number json.Number
+		if err := json.Unmarshal(raw, &number); err == nil {
+			return number.String()
+		}
+		return ""
+	}
+}
+
+// FirstOf returns the first non-empty key produced by the given resolvers, in
+// order, or an empty string if none produce one.
+func FirstOf(resolvers ...Resolver) Resolver {
+	return func(c *fiber.Ctx) string {
+		for _, resolver := range resolvers {
+			if key := resolver(c); key != "" {
+				return key
+			}
+		}
+		return ""
+	}
+}
+
+// Join combines the keys of all resolvers with ":" so that, e.g.,
+// Join(Route(), IP()) limits each client per route. If any resolver produces
+// an empty key, so does Join.
+func Join(resolvers ...Resolver) Resolver {
+	return func(c *fiber.Ctx) string {
+		keys := make([]string, 0, len(resolvers))
+		for _, resolver := range resolvers {
+			key := resolver(c)
+			if key == "" {
+				return ""
+			}
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, ":")
+	}
+}
+
+// Prefix namespaces the key of resolver as "prefix:key" so keys produced by
+// different policies never collide in shared strategy state. Empty keys stay
+// empty.
+func Prefix(prefix string, resolver Resolver) Resolver {
+	return func(c *fiber.Ctx) string {
+		key := resolver(c)
+		if key == "" {
+			return ""
+		}
+		return prefix + ":" + key
+	}
+}
+
+// Classify maps the key of resolver through classes, e.g. to turn an
+// X-Priority header into a priority class for load shedding. Keys missing
+// from classes, including empty ones, map to fallback.
+func Classify(resolver Resolver, classes map[string]string, fallback string) Resolver {
+	return func(c *fiber.Ctx) string {
+		if class, exists := classes[resolver(c)]; exists {
+			return class
+		}
+		return fallback
+	}
+}
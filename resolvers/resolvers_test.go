@@ -0,0 +1,97 @@
+package resolvers
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resolve runs resolver inside a request to target and returns the key it produced.
+func resolve(t *testing.T, pattern, target string, header http.Header, setup fiber.Handler, resolver Resolver) string {
+	t.Helper()
+	app := fiber.New()
+	if setup != nil {
+		app.Use(setup)
+	}
+	app.Get(pattern, func(c *fiber.Ctx) error {
+		return c.SendString(resolver(c))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestHeaderFallsBackToIP(t *testing.T) {
+	resolver := FirstOf(Prefix("key", Header("X-API-Key")), Prefix("ip", IP()))
+
+	if got := resolve(t, "/", "/", http.Header{"X-Api-Key": {"abc"}}, nil, resolver); got != "key:abc" {
+		t.Fatalf("expected key:abc, got %q", got)
+	}
+	if got := resolve(t, "/", "/", nil, nil, resolver); got != "ip:0.0.0.0" {
+		t.Fatalf("expected ip fallback, got %q", got)
+	}
+}
+
+func TestRouteParamAndJoin(t *testing.T) {
+	resolver := Join(Route(), Param("tenant"))
+	if got := resolve(t, "/tenants/:tenant", "/tenants/acme", nil, nil, resolver); got != "GET /tenants/:tenant:acme" {
+		t.Fatalf("unexpected key %q", got)
+	}
+
+	if got := resolve(t, "/", "/", nil, nil, Join(Header("X-Missing"), IP())); got != "" {
+		t.Fatalf("expected empty key when a part is missing, got %q", got)
+	}
+}
+
+func TestLocals(t *testing.T) {
+	setUser := func(c *fiber.Ctx) error {
+		c.Locals("user", 42)
+		return c.Next()
+	}
+	if got := resolve(t, "/", "/", nil, setUser, Locals("user")); got != "42" {
+		t.Fatalf("expected 42, got %q", got)
+	}
+	if got := resolve(t, "/", "/", nil, nil, Locals("user")); got != "" {
+		t.Fatalf("expected empty key without locals, got %q", got)
+	}
+}
+
+func TestJWTClaim(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","org":7}`))
+	token := "Bearer header." + payload + ".signature"
+
+	if got := resolve(t, "/", "/", http.Header{"Authorization": {token}}, nil, JWTClaim("sub")); got != "user-1" {
+		t.Fatalf("expected user-1, got %q", got)
+	}
+	if got := resolve(t, "/", "/", http.Header{"Authorization": {token}}, nil, JWTClaim("org")); got != "7" {
+		t.Fatalf("expected 7, got %q", got)
+	}
+	if got := resolve(t, "/", "/", http.Header{"Authorization": {"Bearer garbage"}}, nil, JWTClaim("sub")); got != "" {
+		t.Fatalf("expected empty key for malformed token, got %q", got)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	resolver := Classify(Header("X-Priority"), map[string]string{"high": "critical", "low": "batch"}, "default")
+
+	if got := resolve(t, "/", "/", http.Header{"X-Priority": {"high"}}, nil, resolver); got != "critical" {
+		t.Fatalf("expected critical, got %q", got)
+	}
+	if got := resolve(t, "/", "/", http.Header{"X-Priority": {"urgent"}}, nil, resolver); got != "default" {
+		t.Fatalf("expected unknown values to fall back, got %q", got)
+	}
+	if got := resolve(t, "/", "/", nil, nil, resolver); got != "default" {
+		t.Fatalf("expected missing header to fall back, got %q", got)
+	}
+}
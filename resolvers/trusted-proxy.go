@@ -0,0 +1,160 @@
+package resolvers
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProxyConfig configures TrustedProxyIP.
+type ProxyConfig struct {
+	// TrustedProxies lists the IP addresses and CIDR ranges of proxies whose
+	// forwarding headers are honored.
+	TrustedProxies []string
+	// Header is the forwarding header the trusted proxies set, such as
+	// X-Forwarded-For, Forwarded or X-Real-IP. Only this header is read, so
+	// a client cannot pick its key by sending one the proxies pass through.
+	// Empty means X-Forwarded-For.
+	Header string
+	// IPv6Prefix aggregates IPv6 client addresses to this prefix length
+	// (e.g. 64) so a single host cannot rotate through its subnet. Zero keeps
+	// full addresses.
+	IPv6Prefix int
+	// IPv4Prefix aggregates IPv4 client addresses likewise. Zero keeps full
+	// addresses.
+	IPv4Prefix int
+}
+
+// TrustedProxyIP creates a resolver that returns the client IP as seen by the
+// first trusted proxy.
+//
+// Parameters:
+//   - config: the trusted proxy ranges and optional address aggregation.
+//
+// Returns:
+//   - Resolver: the resolver, keyed by address or by aggregated prefix in CIDR notation.
+//   - error: if a trusted proxy entry or prefix length is invalid.
+//
+// The forwarding header named by config.Header is only read when the direct
+// peer is a trusted proxy. All of its lines are joined into one chain, which
+// is walked from the right, skipping trusted hops, so entries prepended by the
+// client cannot spoof the key.
+func TrustedProxyIP(config ProxyConfig) (Resolver, error) {
+	if config.IPv6Prefix < 0 || config.IPv6Prefix > 128 {
+		return nil, fmt.Errorf("invalid IPv6 prefix length %d", config.IPv6Prefix)
+	}
+	if config.IPv4Prefix < 0 || config.IPv4Prefix > 32 {
+		return nil, fmt.Errorf("invalid IPv4 prefix length %d", config.IPv4Prefix)
+	}
+
+	trusted := make([]netip.Prefix, 0, len(config.TrustedProxies))
+	for _, entry := range config.TrustedProxies {
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, prefix)
+	}
+	header := config.Header
+	if header == "" {
+		header = fiber.HeaderXForwardedFor
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *fiber.Ctx) string {
+		peer, ok := netip.AddrFromSlice(c.Context().RemoteIP())
+		if !ok {
+			return c.IP()
+		}
+		client := peer.Unmap()
+
+		if isTrusted(client) {
+			if hops := forwardedChain(c, header); len(hops) > 0 {
+				for i := len(hops) - 1; i >= 0; i-- {
+					client = hops[i]
+					if !isTrusted(client) {
+						break
+					}
+				}
+			}
+		}
+
+		return aggregate(client, config.IPv4Prefix, config.IPv6Prefix)
+	}, nil
+}
+
+// forwardedChain returns the parseable addresses of all lines of the named
+// header, ordered from the original client to the nearest proxy. Forwarded
+// headers are read from their for= parameters, others as address lists.
+func forwardedChain(c *fiber.Ctx, header string) []netip.Addr {
+	var hops []netip.Addr
+	for _, line := range c.Request().Header.PeekAll(header) {
+		for _, element := range strings.Split(string(line), ",") {
+			if !strings.EqualFold(header, fiber.HeaderForwarded) {
+				if addr, ok := parseNode(element); ok {
+					hops = append(hops, addr)
+				}
+				continue
+			}
+			for _, pair := range strings.Split(element, ";") {
+				name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(name, "for") {
+					continue
+				}
+				if addr, ok := parseNode(value); ok {
+					hops = append(hops, addr)
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseNode parses a forwarded node such as 192.0.2.1, "[2001:db8::1]:443" or
+// 192.0.2.1:8080.
+func parseNode(value string) (netip.Addr, bool) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func parsePrefix(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if addr, err := netip.ParseAddr(entry); err == nil {
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+	}
+	return prefix.Masked(), nil
+}
+
+func aggregate(addr netip.Addr, ipv4Prefix, ipv6Prefix int) string {
+	bits := ipv6Prefix
+	if addr.Is4() {
+		bits = ipv4Prefix
+	}
+	if bits == 0 || bits >= addr.BitLen() {
+		return addr.String()
+	}
+	prefix, _ := addr.Prefix(bits)
+	return prefix.String()
+}
@@ -0,0 +1,87 @@
+package resolvers
+
+import (
+	"net/http"
+	"testing"
+)
+
+// app.Test requests originate from 0.0.0.0, which the tests treat as the proxy.
+
+func TestTrustedProxyIPIgnoresHeadersFromUntrustedPeer(t *testing.T) {
+	resolver, err := TrustedProxyIP(ProxyConfig{TrustedProxies: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header := http.Header{"X-Forwarded-For": {"203.0.113.9"}}
+	if got := resolve(t, "/", "/", header, nil, resolver); got != "0.0.0.0" {
+		t.Fatalf("expected peer address, got %q", got)
+	}
+}
+
+func TestTrustedProxyIPWalksForwardedChain(t *testing.T) {
+	cases := []struct {
+		name        string
+		proxyHeader string
+		header      http.Header
+		want        string
+	}{
+		{"x-forwarded-for skips trusted hops", "", http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.9, 10.1.2.3"}}, "203.0.113.9"},
+		{"forwarded header", "Forwarded", http.Header{"Forwarded": {`for=198.51.100.1, for="[2001:db8::7]:443";proto=https`}}, "2001:db8::7"},
+		{"x-real-ip", "X-Real-IP", http.Header{"X-Real-Ip": {"198.51.100.2"}}, "198.51.100.2"},
+		{"all hops trusted", "", http.Header{"X-Forwarded-For": {"10.0.0.5"}}, "10.0.0.5"},
+		{"no headers", "", nil, "0.0.0.0"},
+	}
+	for _, tc := range cases {
+		resolver, _ := TrustedProxyIP(ProxyConfig{TrustedProxies: []string{"0.0.0.0", "10.0.0.0/8"}, Header: tc.proxyHeader})
+		if got := resolve(t, "/", "/", tc.header, nil, resolver); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+// The proxy appends to X-Forwarded-For and passes other headers through.
+func TestTrustedProxyIPIgnoresHeadersTheProxyDoesNotSet(t *testing.T) {
+	resolver, _ := TrustedProxyIP(ProxyConfig{TrustedProxies: []string{"0.0.0.0"}, Header: "X-Forwarded-For"})
+
+	header := http.Header{
+		"Forwarded":       {"for=1.2.3.4"},
+		"X-Real-Ip":       {"1.2.3.5"},
+		"X-Forwarded-For": {"203.0.113.9"},
+	}
+	if got := resolve(t, "/", "/", header, nil, resolver); got != "203.0.113.9" {
+		t.Fatalf("expected the address appended by the proxy, got %q", got)
+	}
+}
+
+// A proxy that adds its own X-Forwarded-For line after the client's must win.
+func TestTrustedProxyIPJoinsAllHeaderLines(t *testing.T) {
+	resolver, _ := TrustedProxyIP(ProxyConfig{TrustedProxies: []string{"0.0.0.0"}})
+
+	header := http.Header{"X-Forwarded-For": {"1.2.3.4", "203.0.113.9"}}
+	if got := resolve(t, "/", "/", header, nil, resolver); got != "203.0.113.9" {
+		t.Fatalf("expected the address on the proxy's line, got %q", got)
+	}
+}
+
+func TestTrustedProxyIPAggregatesIPv6(t *testing.T) {
+	resolver, _ := TrustedProxyIP(ProxyConfig{TrustedProxies: []string{"0.0.0.0"}, IPv6Prefix: 64})
+
+	first := resolve(t, "/", "/", http.Header{"X-Forwarded-For": {"2001:db8:1:2::1"}}, nil, resolver)
+	second := resolve(t, "/", "/", http.Header{"X-Forwarded-For": {"2001:db8:1:2:ffff::9"}}, nil, resolver)
+	if first != "2001:db8:1:2::/64" || first != second {
+		t.Fatalf("expected both addresses to share 2001:db8:1:2::/64, got %q and %q", first, second)
+	}
+	if got := resolve(t, "/", "/", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, nil, resolver); got != "198.51.100.1" {
+		t.Fatalf("IPv4 should not be aggregated, got %q", got)
+	}
+}
+
+func TestTrustedProxyIPRejectsInvalidConfig(t *testing.T) {
+	if _, err := TrustedProxyIP(ProxyConfig{TrustedProxies: []string{"not-a-network"}}); err == nil {
+		t.Fatal("expected error for invalid proxy")
+	}
+	if _, err := TrustedProxyIP(ProxyConfig{IPv6Prefix: 129}); err == nil {
+		t.Fatal("expected error for invalid prefix length")
+	}
+}
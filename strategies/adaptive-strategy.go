@@ -0,0 +1,217 @@
+package strategies
+
+import (
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// adaptiveMaxSamples bounds the latencies kept per interval; once reached,
+// the oldest samples are overwritten.
+const adaptiveMaxSamples = 1024
+
+type AdaptiveStrategy struct {
+	Bucket *TokenBucketStrategy
+	// MinRate and MaxRate bound the refill rate.
+	MinRate float64
+	MaxRate float64
+	// Increase is added to the refill rate after each healthy interval.
+	Increase float64
+	// DecreaseFactor multiplies the refill rate after an unhealthy interval.
+	DecreaseFactor float64
+	// LatencyThreshold is the p95 latency above which an interval is
+	// unhealthy; zero disables the check.
+	LatencyThreshold time.Duration
+	// ErrorRateThreshold is the fraction of 5xx responses above which an
+	// interval is unhealthy; zero disables the check.
+	ErrorRateThreshold float64
+	// Interval is how often the health of the service is evaluated.
+	Interval time.Duration
+	// MinSamples is the number of observations an interval needs before it
+	// can be judged unhealthy.
+	MinSamples int
+	// OnAdjust, if set, is called whenever the refill rate changes.
+	OnAdjust func(previous, current float64)
+	// Clock, if set, replaces the system clock.
+	Clock         Clock
+	intervalStart time.Time
+	latencies     []time.Duration
+	observed      int
+	failures      int
+	mutex         sync.Mutex
+}
+
+// NewAdaptiveStrategy wraps a token bucket whose refill rate follows the
+// health of the service, using additive increase and multiplicative decrease.
+//
+// Parameters:
+//   - bucket: the token bucket whose RefillRate is adjusted.
+//   - minRate: lowest refill rate, in tokens per second.
+//   - maxRate: highest refill rate, in tokens per second.
+//   - latencyThreshold: p95 handler latency above which the service is unhealthy.
+//   - errorRateThreshold: fraction of 5xx responses above which the service is unhealthy.
+//
+// Returns:
+//   - *AdaptiveStrategy: a pointer to a new instance of the strategy.
+//
+// The middleware reports the latency and status of every admitted request.
+// Every 10 seconds the observations are evaluated: an unhealthy interval
+// halves the refill rate, and a healthy one raises it by a tenth of the range
+// between minRate and maxRate. Intervals with fewer than 20 observations are
+// treated as healthy. Adjust the exported fields to tune this.
+func NewAdaptiveStrategy(bucket *TokenBucketStrategy, minRate, maxRate float64, latencyThreshold time.Duration, errorRateThreshold float64) *AdaptiveStrategy {
+	return &AdaptiveStrategy{
+		Bucket:             bucket,
+		MinRate:            minRate,
+		MaxRate:            maxRate,
+		Increase:           (maxRate - minRate) / 10,
+		DecreaseFactor:     0.5,
+		LatencyThreshold:   latencyThreshold,
+		ErrorRateThreshold: errorRateThreshold,
+		Interval:           10 * time.Second,
+		MinSamples:         20,
+	}
+}
+
+func (strategy *AdaptiveStrategy) IsRequestAllowed(clientId string) bool {
+	strategy.adjust()
+	return strategy.Bucket.IsRequestAllowed(clientId)
+}
+
+// RetryAfter defers to the token bucket at its current refill rate.
+func (strategy *AdaptiveStrategy) RetryAfter(clientId string) time.Duration {
+	strategy.adjust()
+	return strategy.Bucket.RetryAfter(clientId)
+}
+
+// Observe records the latency and status of an admitted request.
+func (strategy *AdaptiveStrategy) Observe(latency time.Duration, status int) {
+	previous, current := strategy.evaluate()
+
+	strategy.mutex.Lock()
+	if len(strategy.latencies) < adaptiveMaxSamples {
+		strategy.latencies = append(strategy.latencies, latency)
+	} else {
+		strategy.latencies[strategy.observed%adaptiveMaxSamples] = latency
+	}
+	strategy.observed++
+	if status >= 500 {
+		strategy.failures++
+	}
+	strategy.mutex.Unlock()
+
+	strategy.notify(previous, current)
+}
+
+// Rate returns the current refill rate.
+func (strategy *AdaptiveStrategy) Rate() float64 {
+	strategy.adjust()
+	return strategy.Bucket.CurrentRefillRate()
+}
+
+// Clients forwards to the token bucket.
+func (strategy *AdaptiveStrategy) Clients() []string {
+	return strategy.Bucket.Clients()
+}
+
+// Inspect forwards to the token bucket.
+func (strategy *AdaptiveStrategy) Inspect(clientId string) (ClientState, bool) {
+	return strategy.Bucket.Inspect(clientId)
+}
+
+// Reset forwards to the token bucket.
+func (strategy *AdaptiveStrategy) Reset(clientId string) {
+	strategy.Bucket.Reset(clientId)
+}
+
+// Charge forwards to the token bucket.
+func (strategy *AdaptiveStrategy) Charge(clientId string, n int) {
+	strategy.Bucket.Charge(clientId, n)
+}
+
+// Refund forwards to the token bucket.
+func (strategy *AdaptiveStrategy) Refund(clientId string, n int) {
+	strategy.Bucket.Refund(clientId, n)
+}
+
+// Snapshot writes the token buckets to w. The refill rate is not included;
+// a restored strategy starts from the bucket's configured rate.
+func (strategy *AdaptiveStrategy) Snapshot(w io.Writer) error {
+	return strategy.Bucket.Snapshot(w)
+}
+
+// Restore forwards to the token bucket.
+func (strategy *AdaptiveStrategy) Restore(r io.Reader) error {
+	return strategy.Bucket.Restore(r)
+}
+
+// adjust evaluates any completed intervals and reports a rate change.
+func (strategy *AdaptiveStrategy) adjust() {
+	strategy.notify(strategy.evaluate())
+}
+
+func (strategy *AdaptiveStrategy) notify(previous, current float64) {
+	if previous != current && strategy.OnAdjust != nil {
+		strategy.OnAdjust(previous, current)
+	}
+}
+
+// evaluate applies one decrease or increase per completed interval and
+// returns the refill rate before and after.
+func (strategy *AdaptiveStrategy) evaluate() (float64, float64) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	if strategy.intervalStart.IsZero() {
+		strategy.intervalStart = now
+	}
+	if strategy.Interval <= 0 || now.Sub(strategy.intervalStart) < strategy.Interval {
+		return 0, 0
+	}
+
+	intervals := int(now.Sub(strategy.intervalStart) / strategy.Interval)
+	strategy.intervalStart = strategy.intervalStart.Add(time.Duration(intervals) * strategy.Interval)
+
+	previous := strategy.Bucket.CurrentRefillRate()
+	rate := previous
+	if strategy.unhealthy() {
+		rate *= strategy.DecreaseFactor
+		intervals--
+	}
+	// Intervals without traffic count as healthy.
+	rate += strategy.Increase * float64(intervals)
+	rate = math.Min(strategy.MaxRate, math.Max(strategy.MinRate, rate))
+
+	strategy.latencies = strategy.latencies[:0]
+	strategy.observed = 0
+	strategy.failures = 0
+
+	if rate != previous {
+		strategy.Bucket.SetRefillRate(rate)
+	}
+	return previous, rate
+}
+
+// unhealthy reports whether the current interval crossed a threshold. The
+// caller must hold the mutex.
+func (strategy *AdaptiveStrategy) unhealthy() bool {
+	if strategy.observed == 0 || strategy.observed < strategy.MinSamples {
+		return false
+	}
+
+	errorRate := float64(strategy.failures) / float64(strategy.observed)
+	if strategy.ErrorRateThreshold > 0 && errorRate > strategy.ErrorRateThreshold {
+		return true
+	}
+	if strategy.LatencyThreshold <= 0 {
+		return false
+	}
+
+	sorted := append([]time.Duration(nil), strategy.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	p95 := sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+	return p95 > strategy.LatencyThreshold
+}
@@ -0,0 +1,104 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+)
+
+func newAdaptive(clock strategies.Clock) *strategies.AdaptiveStrategy {
+	bucket := strategies.NewTokenBucketStrategy(100, 10)
+	bucket.Clock = clock
+	strategy := strategies.NewAdaptiveStrategy(bucket, 10, 100, 100*time.Millisecond, 0.1)
+	strategy.Clock = clock
+	strategy.MinSamples = 5
+	return strategy
+}
+
+func TestAdaptiveDecreasesOnSlowResponses(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := newAdaptive(clock)
+
+	var adjustments [][2]float64
+	strategy.OnAdjust = func(previous, current float64) {
+		adjustments = append(adjustments, [2]float64{previous, current})
+	}
+
+	for i := 0; i < 10; i++ {
+		strategy.Observe(500*time.Millisecond, 200)
+	}
+	clock.Advance(strategy.Interval)
+	if rate := strategy.Rate(); rate != 50 {
+		t.Fatalf("expected rate to halve to 50, got %v", rate)
+	}
+
+	for interval := 0; interval < 3; interval++ {
+		for i := 0; i < 10; i++ {
+			strategy.Observe(500*time.Millisecond, 200)
+		}
+		clock.Advance(strategy.Interval)
+	}
+	if rate := strategy.Rate(); rate != 10 {
+		t.Fatalf("expected rate to be clamped to MinRate 10, got %v", rate)
+	}
+	if len(adjustments) != 4 || adjustments[0] != [2]float64{100, 50} {
+		t.Fatalf("unexpected adjustments: %v", adjustments)
+	}
+}
+
+func TestAdaptiveDecreasesOnErrors(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := newAdaptive(clock)
+
+	for i := 0; i < 10; i++ {
+		status := 200
+		if i < 2 {
+			status = 503
+		}
+		strategy.Observe(time.Millisecond, status)
+	}
+	clock.Advance(strategy.Interval)
+	if rate := strategy.Rate(); rate != 50 {
+		t.Fatalf("expected a 20%% error rate to halve the rate, got %v", rate)
+	}
+}
+
+func TestAdaptiveRecoversAdditively(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := newAdaptive(clock)
+	strategy.Bucket.SetRefillRate(10)
+
+	// Too few samples to judge the interval unhealthy.
+	strategy.Observe(time.Second, 500)
+	clock.Advance(strategy.Interval)
+	if rate := strategy.Rate(); rate != 19 {
+		t.Fatalf("expected rate 19 after one healthy interval, got %v", rate)
+	}
+
+	// Idle intervals count as healthy, up to MaxRate.
+	clock.Advance(20 * strategy.Interval)
+	if rate := strategy.Rate(); rate != 100 {
+		t.Fatalf("expected rate to recover to MaxRate 100, got %v", rate)
+	}
+}
+
+func TestTokenBucketSetRefillRateIsNotRetroactive(t *testing.T) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	bucket := strategies.NewTokenBucketStrategy(1, 10)
+	bucket.Clock = clock
+	bucket.Charge("client", 10)
+
+	clock.Advance(2 * time.Second)
+	bucket.SetRefillRate(100)
+
+	state, _ := bucket.Inspect("client")
+	if state.Tokens != 2 {
+		t.Fatalf("expected 2 tokens refilled at the old rate, got %v", state.Tokens)
+	}
+	clock.Advance(50 * time.Millisecond)
+	if state, _ := bucket.Inspect("client"); state.Tokens != 7 {
+		t.Fatalf("expected 7 tokens after refilling at the new rate, got %v", state.Tokens)
+	}
+}
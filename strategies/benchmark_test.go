@@ -0,0 +1,67 @@
+package strategies
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// benchmarkStrategy measures a strategy hammered by one client, by many
+// clients, and by many goroutines at once.
+func benchmarkStrategy(b *testing.B, newStrategy func() RateLimitStrategy) {
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = "client-" + strconv.Itoa(i)
+	}
+
+	b.Run("single-key", func(b *testing.B) {
+		s := newStrategy()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			s.IsRequestAllowed("client")
+		}
+	})
+
+	b.Run("many-keys", func(b *testing.B) {
+		s := newStrategy()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			s.IsRequestAllowed(keys[i%len(keys)])
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		s := newStrategy()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				s.IsRequestAllowed(keys[i%len(keys)])
+				i++
+			}
+		})
+	})
+}
+
+func BenchmarkFixedWindow(b *testing.B) {
+	benchmarkStrategy(b, func() RateLimitStrategy { return NewFixedWindowStrategy(100, time.Second) })
+}
+
+func BenchmarkSlidingWindow(b *testing.B) {
+	benchmarkStrategy(b, func() RateLimitStrategy { return NewSlidingWindowStrategy(100, time.Second) })
+}
+
+func BenchmarkTokenBucket(b *testing.B) {
+	benchmarkStrategy(b, func() RateLimitStrategy { return NewTokenBucketStrategy(100, 100) })
+}
+
+func BenchmarkLeakyBucket(b *testing.B) {
+	benchmarkStrategy(b, func() RateLimitStrategy { return NewLeakyBucketStrategy(100, 100) })
+}
+
+func BenchmarkQuota(b *testing.B) {
+	benchmarkStrategy(b, func() RateLimitStrategy { return NewQuotaStrategy(100, Daily, time.UTC) })
+}
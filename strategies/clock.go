@@ -0,0 +1,17 @@
+package strategies
+
+import "time"
+
+// Clock tells strategies the current time. Tests and simulations substitute a
+// virtual clock to run deterministically; a nil Clock means the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// clockNow returns clock.Now(), or time.Now() if clock is nil.
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
@@ -0,0 +1,89 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+)
+
+var conformanceConfigs = map[string]strategytest.Config{
+	"fixed-window": {
+		New: func(clock strategies.Clock) strategies.RateLimitStrategy {
+			s := strategies.NewFixedWindowStrategy(5, time.Second)
+			s.Clock = clock
+			return s
+		},
+		Window:       time.Second,
+		MaxPerWindow: 10, // a full window's worth on each side of a boundary
+	},
+	"aligned-fixed-window": {
+		New: func(clock strategies.Clock) strategies.RateLimitStrategy {
+			s := strategies.NewAlignedFixedWindowStrategy(5, time.Second)
+			s.Clock = clock
+			return s
+		},
+		Window:       time.Second,
+		MaxPerWindow: 10,
+	},
+	"sliding-window": {
+		New: func(clock strategies.Clock) strategies.RateLimitStrategy {
+			s := strategies.NewSlidingWindowStrategy(5, time.Second)
+			s.Clock = clock
+			return s
+		},
+		Window:       time.Second,
+		MaxPerWindow: 5,
+	},
+	"token-bucket": {
+		New: func(clock strategies.Clock) strategies.RateLimitStrategy {
+			s := strategies.NewTokenBucketStrategy(3, 5)
+			s.Clock = clock
+			return s
+		},
+		Window:       time.Second,
+		MaxPerWindow: 8, // burst of 5 plus 3 refilled per second
+	},
+	"leaky-bucket": {
+		New: func(clock strategies.Clock) strategies.RateLimitStrategy {
+			s := strategies.NewLeakyBucketStrategy(3, 5)
+			s.Clock = clock
+			return s
+		},
+		Window:       time.Second,
+		MaxPerWindow: 8,
+	},
+	"quota": {
+		New: func(clock strategies.Clock) strategies.RateLimitStrategy {
+			s := strategies.NewQuotaStrategy(20, strategies.Hourly, time.UTC)
+			s.Clock = clock
+			return s
+		},
+		Window:       time.Hour,
+		MaxPerWindow: 40,
+		MaxGap:       5 * time.Minute,
+	},
+}
+
+func TestConformance(t *testing.T) {
+	for name, cfg := range conformanceConfigs {
+		t.Run(name, func(t *testing.T) {
+			strategytest.Run(t, cfg)
+		})
+	}
+}
+
+func fuzzConformance(f *testing.F, name string) {
+	cfg := conformanceConfigs[name]
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 255, 1, 10})
+	f.Add([]byte{0, 64, 1, 64, 0, 64, 1, 64, 0, 255, 0, 0, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		strategytest.Check(t, cfg, strategytest.ScheduleFromBytes(data, cfg))
+	})
+}
+
+func FuzzFixedWindow(f *testing.F)   { fuzzConformance(f, "fixed-window") }
+func FuzzSlidingWindow(f *testing.F) { fuzzConformance(f, "sliding-window") }
+func FuzzTokenBucket(f *testing.F)   { fuzzConformance(f, "token-bucket") }
+func FuzzLeakyBucket(f *testing.F)   { fuzzConformance(f, "leaky-bucket") }
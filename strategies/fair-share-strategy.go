@@ -0,0 +1,249 @@
+package strategies
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+type FairShareStrategy struct {
+	// Limit is the global number of requests allowed within the window.
+	Limit      int
+	WindowSize time.Duration
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string][]time.Time
+	// denied holds when each client was last denied, so clients that want
+	// more than they get claim a share even with nothing in the window.
+	denied map[string]time.Time
+	mutex  sync.Mutex
+}
+
+// NewFairShareStrategy creates a strategy that splits a global capacity fairly
+// across the clients that are currently active.
+//
+// Parameters:
+//   - limit: global number of requests allowed within the sliding window.
+//   - windowSize: duration of the sliding time window.
+//
+// Returns:
+//   - *FairShareStrategy: a pointer to a new instance of the strategy.
+//
+// A client is active while it has requests inside the window or was denied
+// within it. Each request is admitted if the window holds fewer than limit
+// requests overall and the client is under its max-min fair share: clients
+// using less than an equal split and not denied keep what they use, and the
+// rest is divided equally among the others.
+// Shares are recomputed on every request, so they grow as clients go idle and
+// shrink as new ones arrive, and a heavy client cannot starve the rest.
+// Every request scans all active clients.
+func NewFairShareStrategy(limit int, windowSize time.Duration) *FairShareStrategy {
+	return &FairShareStrategy{
+		Limit:      limit,
+		WindowSize: windowSize,
+		clients:    make(map[string][]time.Time),
+		denied:     make(map[string]time.Time),
+	}
+}
+
+func (strategy *FairShareStrategy) IsRequestAllowed(clientId string) bool {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	total := strategy.prune(now)
+	timestamps := strategy.clients[clientId]
+	if total >= strategy.Limit || float64(len(timestamps)) >= strategy.share(clientId) {
+		strategy.denied[clientId] = now
+		return false
+	}
+
+	strategy.clients[clientId] = append(timestamps, now)
+	return true
+}
+
+// RetryAfter returns how long until the client's oldest request leaves the
+// window if it is at its share, or until the oldest request overall does if
+// the global limit is reached.
+func (strategy *FairShareStrategy) RetryAfter(clientId string) time.Duration {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.prune(now)
+	return strategy.retryAfter(clientId, now)
+}
+
+// Clients returns the ids of all clients with requests inside the window,
+// sorted.
+func (strategy *FairShareStrategy) Clients() []string {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.prune(now)
+	ids := make([]string, 0, len(strategy.clients))
+	for id := range strategy.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Share returns the number of requests clientId may currently have inside the
+// window.
+func (strategy *FairShareStrategy) Share(clientId string) float64 {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.prune(now)
+	return strategy.share(clientId)
+}
+
+// Inspect reports the number of the client's requests inside the window.
+// Limit is the global limit; Remaining is bounded by the client's fair share.
+func (strategy *FairShareStrategy) Inspect(clientId string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	total := strategy.prune(now)
+	timestamps, exists := strategy.clients[clientId]
+	share := strategy.share(clientId)
+	remaining := math.Ceil(share) - float64(len(timestamps))
+	return ClientState{
+		ClientId:   clientId,
+		Limit:      float64(strategy.Limit),
+		Count:      len(timestamps),
+		Remaining:  math.Max(0, math.Min(remaining, float64(strategy.Limit-total))),
+		RetryAfter: strategy.retryAfter(clientId, now),
+	}, exists
+}
+
+// Reset forgets all of the client's requests.
+func (strategy *FairShareStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	delete(strategy.clients, clientId)
+	delete(strategy.denied, clientId)
+}
+
+// Charge records n requests at the current time, up to the global Limit.
+func (strategy *FairShareStrategy) Charge(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	total := strategy.prune(now)
+	timestamps := strategy.clients[clientId]
+	for i := 0; i < n && total < strategy.Limit; i++ {
+		timestamps = append(timestamps, now)
+		total++
+	}
+	if len(timestamps) > 0 {
+		strategy.clients[clientId] = timestamps
+	}
+}
+
+// Refund removes the client's n most recent requests.
+func (strategy *FairShareStrategy) Refund(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.prune(now)
+	timestamps, exists := strategy.clients[clientId]
+	if !exists {
+		return
+	}
+	timestamps = timestamps[:len(timestamps)-min(len(timestamps), max(0, n))]
+	if len(timestamps) == 0 {
+		delete(strategy.clients, clientId)
+		return
+	}
+	strategy.clients[clientId] = timestamps
+}
+
+// prune drops requests and denials that have left the window and clients that
+// have gone idle, and returns the number of requests left. The caller must
+// hold the mutex.
+func (strategy *FairShareStrategy) prune(now time.Time) int {
+	for id, at := range strategy.denied {
+		if now.Sub(at) >= strategy.WindowSize {
+			delete(strategy.denied, id)
+		}
+	}
+
+	total := 0
+	for id, timestamps := range strategy.clients {
+		stale := sort.Search(len(timestamps), func(i int) bool {
+			return now.Sub(timestamps[i]) < strategy.WindowSize
+		})
+		if stale == len(timestamps) {
+			delete(strategy.clients, id)
+			continue
+		}
+		if stale > 0 {
+			strategy.clients[id] = timestamps[stale:]
+		}
+		total += len(timestamps) - stale
+	}
+	return total
+}
+
+// share returns the max-min fair share of clientId, assuming it wants as much
+// as it can get. Recently denied clients are assumed to want as much too. The
+// caller must hold the mutex.
+func (strategy *FairShareStrategy) share(clientId string) float64 {
+	usage := make([]int, 0, len(strategy.clients)+len(strategy.denied))
+	for id, timestamps := range strategy.clients {
+		if _, denied := strategy.denied[id]; id != clientId && !denied {
+			usage = append(usage, len(timestamps))
+		}
+	}
+	for id := range strategy.denied {
+		if id != clientId {
+			usage = append(usage, math.MaxInt)
+		}
+	}
+	sort.Ints(usage)
+
+	capacity := float64(strategy.Limit)
+	claimants := len(usage) + 1
+	for _, used := range usage {
+		if float64(used) >= capacity/float64(claimants) {
+			break
+		}
+		capacity -= float64(used)
+		claimants--
+	}
+	return capacity / float64(claimants)
+}
+
+// retryAfter estimates when the client is next admitted, assuming the other
+// clients stay as they are. It must be called with the mutex held, after
+// prune.
+func (strategy *FairShareStrategy) retryAfter(clientId string, now time.Time) time.Duration {
+	var oldest time.Time
+	total := 0
+	for _, timestamps := range strategy.clients {
+		total += len(timestamps)
+		if oldest.IsZero() || timestamps[0].Before(oldest) {
+			oldest = timestamps[0]
+		}
+	}
+
+	timestamps := strategy.clients[clientId]
+	if share := strategy.share(clientId); len(timestamps) > 0 && float64(len(timestamps)) >= share {
+		// Wait until enough of the client's requests expire to fall under its
+		// current share.
+		expired := min(len(timestamps), len(timestamps)-int(math.Ceil(share))+1)
+		oldest = timestamps[max(1, expired)-1]
+	} else if total < strategy.Limit {
+		return 0
+	}
+	return max(0, oldest.Add(strategy.WindowSize).Sub(now))
+}
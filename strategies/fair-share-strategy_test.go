@@ -0,0 +1,117 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+)
+
+func newFairShare() (*strategies.FairShareStrategy, *strategytest.FakeClock) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewFairShareStrategy(12, time.Second)
+	strategy.Clock = clock
+	return strategy, clock
+}
+
+func TestFairShareSingleClientGetsFullCapacity(t *testing.T) {
+	strategy, _ := newFairShare()
+	if got := admitted(strategy, "heavy", 20); got != 12 {
+		t.Fatalf("expected a lone client to use the whole capacity, got %d", got)
+	}
+}
+
+func TestFairShareSplitsEquallyAmongActiveClients(t *testing.T) {
+	strategy, clock := newFairShare()
+
+	for i := 0; i < 20; i++ {
+		for _, client := range []string{"a", "b", "c"} {
+			strategy.IsRequestAllowed(client)
+		}
+	}
+	for _, client := range []string{"a", "b", "c"} {
+		if state, _ := strategy.Inspect(client); state.Count != 4 {
+			t.Fatalf("expected %s to get an equal share of 4, got %d", client, state.Count)
+		}
+	}
+
+	// Once b and c go idle, a may use the whole capacity again.
+	clock.Advance(time.Second)
+	if got := admitted(strategy, "a", 20); got != 12 {
+		t.Fatalf("expected a to reclaim the idle capacity, got %d", got)
+	}
+}
+
+func TestFairShareLightClientsKeepTheirUsage(t *testing.T) {
+	strategy, _ := newFairShare()
+
+	admitted(strategy, "light", 2)
+	if share := strategy.Share("heavy"); share != 10 {
+		t.Fatalf("expected heavy to get what light leaves, 10, got %v", share)
+	}
+	if got := admitted(strategy, "heavy", 20); got != 10 {
+		t.Fatalf("expected heavy to be admitted 10 times, got %d", got)
+	}
+}
+
+func TestFairShareHeavyClientYieldsToNewcomer(t *testing.T) {
+	strategy, clock := newFairShare()
+
+	for i := 0; i < 12; i++ {
+		strategy.IsRequestAllowed("heavy")
+		clock.Advance(50 * time.Millisecond)
+	}
+	if strategy.IsRequestAllowed("newcomer") {
+		t.Fatal("expected the global limit to hold")
+	}
+
+	// At 1.3s heavy's requests from 0ms to 300ms have expired, leaving it 5.
+	clock.Advance(700 * time.Millisecond)
+	if got := admitted(strategy, "newcomer", 20); got != 7 {
+		t.Fatalf("expected the newcomer to take the 7 freed slots, got %d", got)
+	}
+
+	// Heavy is now held to an equal share of 6 and the window is full, so it
+	// waits for its next request to expire at 1.35s.
+	if strategy.IsRequestAllowed("heavy") {
+		t.Fatal("expected heavy to be denied while the window is full")
+	}
+	if retry := strategy.RetryAfter("heavy"); retry != 50*time.Millisecond {
+		t.Fatalf("expected heavy to retry in 50ms, got %s", retry)
+	}
+}
+
+func TestFairShareRefund(t *testing.T) {
+	strategy, _ := newFairShare()
+	admitted(strategy, "a", 5)
+	strategy.Refund("a", 2)
+
+	if state, _ := strategy.Inspect("a"); state.Count != 3 || state.Remaining != 9 {
+		t.Fatalf("unexpected state after refund: %+v", state)
+	}
+}
+
+// A client that arrives after a heavy one filled the window is denied with
+// nothing in the window, yet must still claim its share as slots free up.
+func TestFairShareLateClientIsNotStarved(t *testing.T) {
+	strategy, clock := newFairShare()
+
+	// Heavy keeps the window full, asking three times every 50ms and before
+	// light each time; light arrives after the first second.
+	heavy, light := 0, 0
+	for tick := 0; tick < 100; tick++ {
+		heavy += admitted(strategy, "heavy", 3)
+		if tick >= 20 && strategy.IsRequestAllowed("light") {
+			light++
+		}
+		clock.Advance(50 * time.Millisecond)
+	}
+
+	if light < 20 {
+		t.Fatalf("expected light to get a fair share over 4s, got %d against heavy's %d", light, heavy)
+	}
+	if state, _ := strategy.Inspect("light"); state.Count != 6 {
+		t.Fatalf("expected light to hold an equal share of 6, got %d", state.Count)
+	}
+}
@@ -1,6 +1,8 @@
 package strategies
 
 import (
+	"io"
+	"sort"
 	"sync"
 	"time"
 )
@@ -8,13 +10,18 @@ import (
 type FixedWindowStrategy struct {
 	Limit      int
 	WindowSize time.Duration
-	clients    map[string]*fixedWindowState
-	mutex      sync.Mutex
+	// Aligned starts every window on a multiple of WindowSize since the Unix
+	// epoch instead of at each client's first request.
+	Aligned bool
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string]*fixedWindowState
+	mutex   sync.Mutex
 }
 
 type fixedWindowState struct {
-	WindowStart  time.Time
-	RequestCount int
+	WindowStart  time.Time `json:"windowStart"`
+	RequestCount int       `json:"requestCount"`
 }
 
 // NewFixedWindowStrategy creates a new Fixed Window rate limiting strategy.
@@ -35,21 +42,31 @@ func NewFixedWindowStrategy(limit int, windowSize time.Duration) *FixedWindowStr
 	}
 }
 
+// NewAlignedFixedWindowStrategy creates a Fixed Window strategy whose windows
+// are aligned to epoch boundaries.
+//
+// Parameters:
+//   - limit: maximum number of allowed requests per window.
+//   - windowSize: duration of the fixed time window, e.g. time.Minute for
+//     windows starting every minute on the minute.
+//
+// Returns:
+//   - *FixedWindowStrategy: a pointer to a new instance of the strategy.
+//
+// All clients share the same window boundaries, so they reset at the same
+// time and instances on different nodes agree on the current window.
+func NewAlignedFixedWindowStrategy(limit int, windowSize time.Duration) *FixedWindowStrategy {
+	strategy := NewFixedWindowStrategy(limit, windowSize)
+	strategy.Aligned = true
+	return strategy
+}
+
 func (strategy *FixedWindowStrategy) IsRequestAllowed(clientId string) bool {
-	now := time.Now()
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
-	state, exists := strategy.clients[clientId]
-	if !exists {
-		state = &fixedWindowState{WindowStart: now, RequestCount: 0}
-		strategy.clients[clientId] = state
-	}
-
-	if now.After(state.WindowStart.Add(strategy.WindowSize)) {
-		state.WindowStart = now
-		state.RequestCount = 0
-	}
+	state := strategy.state(clientId, now)
 
 	if state.RequestCount < strategy.Limit {
 		state.RequestCount++
@@ -62,7 +79,7 @@ func (strategy *FixedWindowStrategy) IsRequestAllowed(clientId string) bool {
 // RetryAfter returns the remaining time in the current window before another
 // request would be allowed.
 func (strategy *FixedWindowStrategy) RetryAfter(clientId string) time.Duration {
-	now := time.Now()
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
@@ -71,9 +88,163 @@ func (strategy *FixedWindowStrategy) RetryAfter(clientId string) time.Duration {
 		return 0
 	}
 
+	return strategy.retryAfter(state, now)
+}
+
+// Clients returns the ids of all tracked clients, sorted.
+func (strategy *FixedWindowStrategy) Clients() []string {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	ids := make([]string, 0, len(strategy.clients))
+	for id := range strategy.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Inspect reports the request count of the client's current window.
+func (strategy *FixedWindowStrategy) Inspect(clientId string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	result := ClientState{
+		ClientId:  clientId,
+		Limit:     float64(strategy.Limit),
+		Remaining: float64(strategy.Limit),
+	}
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		return result, false
+	}
+	if strategy.expired(state, now) {
+		return result, true
+	}
+
+	result.Count = state.RequestCount
+	result.Remaining = float64(max(0, strategy.Limit-state.RequestCount))
+	result.WindowStart = state.WindowStart
+	if result.Remaining == 0 {
+		result.RetryAfter = strategy.retryAfter(state, now)
+	}
+	return result, true
+}
+
+// Reset forgets the client's current window.
+func (strategy *FixedWindowStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	delete(strategy.clients, clientId)
+}
+
+// Charge adds n requests to the client's current window, up to Limit.
+func (strategy *FixedWindowStrategy) Charge(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state := strategy.state(clientId, now)
+	state.RequestCount = min(strategy.Limit, state.RequestCount+max(0, n))
+}
+
+// Refund removes n requests from the client's current window.
+func (strategy *FixedWindowStrategy) Refund(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	if _, exists := strategy.clients[clientId]; !exists {
+		return
+	}
+	state := strategy.state(clientId, now)
+	state.RequestCount = max(0, state.RequestCount-max(0, n))
+}
+
+// Snapshot writes the windows of all clients to w.
+func (strategy *FixedWindowStrategy) Snapshot(w io.Writer) error {
+	strategy.mutex.Lock()
+	clients := make(map[string]fixedWindowState, len(strategy.clients))
+	for id, state := range strategy.clients {
+		clients[id] = *state
+	}
+	strategy.mutex.Unlock()
+
+	return writeSnapshot(w, "fixed-window", clockNow(strategy.Clock), clients)
+}
+
+// Restore replaces all windows with those read from r, dropping windows that
+// have ended in the meantime.
+func (strategy *FixedWindowStrategy) Restore(r io.Reader) error {
+	clients, err := readSnapshot[fixedWindowState](r, "fixed-window")
+	if err != nil {
+		return err
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.clients = make(map[string]*fixedWindowState, len(clients))
+	for id, state := range clients {
+		state.WindowStart = notAfter(state.WindowStart, now)
+		if strategy.Aligned {
+			state.WindowStart = strategy.alignedStart(state.WindowStart)
+		}
+		if strategy.expired(&state, now) {
+			continue
+		}
+		strategy.clients[id] = &state
+	}
+	return nil
+}
+
+// state returns the client's window, creating or rolling it over as needed.
+// The caller must hold the mutex.
+func (strategy *FixedWindowStrategy) state(clientId string, now time.Time) *fixedWindowState {
+	windowStart := now
+	if strategy.Aligned {
+		windowStart = strategy.alignedStart(now)
+	}
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		state = &fixedWindowState{WindowStart: windowStart, RequestCount: 0}
+		strategy.clients[clientId] = state
+	}
+
+	if strategy.expired(state, now) {
+		state.WindowStart = windowStart
+		state.RequestCount = 0
+	}
+
+	return state
+}
+
+// expired reports whether state belongs to a window that has ended. Windows
+// are half-open, so a client told to retry after the window end is admitted
+// exactly then.
+func (strategy *FixedWindowStrategy) expired(state *fixedWindowState, now time.Time) bool {
+	return !now.Before(state.WindowStart.Add(strategy.WindowSize))
+}
+
+// alignedStart returns the start of the epoch-aligned window containing now.
+func (strategy *FixedWindowStrategy) alignedStart(now time.Time) time.Time {
+	if strategy.WindowSize <= 0 {
+		return now
+	}
+	nanos := now.UnixNano()
+	return time.Unix(0, nanos-nanos%int64(strategy.WindowSize))
+}
+
+// retryAfter must be called with the mutex held.
+func (strategy *FixedWindowStrategy) retryAfter(state *fixedWindowState, now time.Time) time.Duration {
 	// If the window has already rolled, allow immediately.
 	windowEnd := state.WindowStart.Add(strategy.WindowSize)
-	if now.After(windowEnd) {
+	if !now.Before(windowEnd) {
 		return 0
 	}
 
@@ -156,3 +156,106 @@ func TestRetryAfter_FixedWindow(t *testing.T) {
 		t.Fatal("expected zero retry-after after window elapsed")
 	}
 }
+
+// Windows are half-open: a client told to retry at the window end is
+// admitted exactly then, for both unaligned and aligned windows.
+func TestWindowEndBoundary_FixedWindow(t *testing.T) {
+	start := time.Unix(0, 0).Add(time.Hour)
+	for _, s := range []*FixedWindowStrategy{NewFixedWindowStrategy(1, time.Minute), NewAlignedFixedWindowStrategy(1, time.Minute)} {
+		clock := fixedClock(start)
+		s.Clock = &clock
+
+		if !s.IsRequestAllowed("userA") || s.IsRequestAllowed("userA") {
+			t.Fatalf("aligned=%v: expected one admission in the window", s.Aligned)
+		}
+		if wait := s.RetryAfter("userA"); wait != time.Minute {
+			t.Fatalf("aligned=%v: expected to wait the whole window, got %v", s.Aligned, wait)
+		}
+
+		clock = fixedClock(start.Add(time.Minute - time.Nanosecond))
+		if s.IsRequestAllowed("userA") {
+			t.Fatalf("aligned=%v: expected denial just before the window end", s.Aligned)
+		}
+		clock = fixedClock(start.Add(time.Minute))
+		if wait := s.RetryAfter("userA"); wait != 0 {
+			t.Fatalf("aligned=%v: expected zero retry-after at the window end, got %v", s.Aligned, wait)
+		}
+		if !s.IsRequestAllowed("userA") {
+			t.Fatalf("aligned=%v: expected admission at the window end", s.Aligned)
+		}
+	}
+}
+
+// Inspect, Charge and Reset should observe and manipulate the current window.
+func TestInspectChargeReset_FixedWindow(t *testing.T) {
+	s := NewFixedWindowStrategy(3, time.Minute)
+	client := "userA"
+
+	if state, tracked := s.Inspect(client); tracked || state.Remaining != 3 {
+		t.Fatalf("untracked client: unexpected state %+v", state)
+	}
+
+	_ = s.IsRequestAllowed(client)
+	s.Charge(client, 5)
+	state, tracked := s.Inspect(client)
+	if !tracked || state.Count != 3 || state.Remaining != 0 || state.RetryAfter <= 0 {
+		t.Fatalf("after charge: unexpected state %+v", state)
+	}
+
+	s.Reset(client)
+	if clients := s.Clients(); len(clients) != 0 {
+		t.Fatalf("expected no tracked clients after reset, got %v", clients)
+	}
+	if !s.IsRequestAllowed(client) {
+		t.Fatal("expected allowed after reset")
+	}
+}
+
+// Concurrent refunds should free window slots without going below zero.
+func TestRefund_FixedWindow(t *testing.T) {
+	limit := 50
+	s := NewFixedWindowStrategy(limit, time.Minute)
+	client := "userA"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.IsRequestAllowed(client) {
+				s.Refund(client, 1)
+			}
+			s.Refund(client, 1)
+		}()
+	}
+	wg.Wait()
+
+	if state, _ := s.Inspect(client); state.Count != 0 || state.Remaining != float64(limit) {
+		t.Fatalf("expected empty window, got %+v", state)
+	}
+}
+
+// Aligned windows start on epoch multiples of the window size for every client.
+func TestAlignedWindows_FixedWindow(t *testing.T) {
+	window := 200 * time.Millisecond
+	s := NewAlignedFixedWindowStrategy(1, window)
+
+	_ = s.IsRequestAllowed("userA")
+	_ = s.IsRequestAllowed("userB")
+
+	a, _ := s.Inspect("userA")
+	b, _ := s.Inspect("userB")
+	if a.WindowStart.UnixNano()%int64(window) != 0 || b.WindowStart.UnixNano()%int64(window) != 0 {
+		t.Fatalf("window starts %v and %v are not aligned to %v", a.WindowStart, b.WindowStart, window)
+	}
+
+	// the window ends at the next boundary, not a full window after the first request
+	wait := s.RetryAfter("userB")
+	if wait > window {
+		t.Fatalf("expected retry-after within one window, got %v", wait)
+	}
+	time.Sleep(wait + 2*time.Millisecond)
+	if !s.IsRequestAllowed("userB") {
+		t.Fatal("expected allowed once the aligned window rolled over")
+	}
+}
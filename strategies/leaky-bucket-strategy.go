@@ -1,7 +1,9 @@
 package strategies
 
 import (
+	"io"
 	"math"
+	"sort"
 	"sync"
 	"time"
 )
@@ -9,13 +11,15 @@ import (
 type LeakyBucketStrategy struct {
 	LeakRate   float64
 	BucketSize float64
-	clients    map[string]*leakyBucketState
-	mutex      sync.Mutex
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string]*leakyBucketState
+	mutex   sync.Mutex
 }
 
 type leakyBucketState struct {
-	QueuedRequests float64
-	LastLeak       time.Time
+	QueuedRequests float64   `json:"queuedRequests"`
+	LastLeak       time.Time `json:"lastLeak"`
 }
 
 // NewLeakyBucketStrategy creates a new Leaky Bucket rate limiting strategy.
@@ -38,20 +42,11 @@ func NewLeakyBucketStrategy(leakRate, bucketSize float64) *LeakyBucketStrategy {
 }
 
 func (strategy *LeakyBucketStrategy) IsRequestAllowed(clientId string) bool {
-	now := time.Now()
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
-	state, exists := strategy.clients[clientId]
-	if !exists {
-		state = &leakyBucketState{QueuedRequests: 0, LastLeak: now}
-		strategy.clients[clientId] = state
-	}
-
-	elapsed := now.Sub(state.LastLeak).Seconds()
-	leaked := elapsed * strategy.LeakRate
-	state.QueuedRequests = math.Max(0, state.QueuedRequests-leaked)
-	state.LastLeak = now
+	state := strategy.state(clientId, now)
 
 	if state.QueuedRequests < strategy.BucketSize {
 		state.QueuedRequests++
@@ -63,7 +58,7 @@ func (strategy *LeakyBucketStrategy) IsRequestAllowed(clientId string) bool {
 
 // RetryAfter returns how long until at least one slot becomes available.
 func (strategy *LeakyBucketStrategy) RetryAfter(clientId string) time.Duration {
-	now := time.Now()
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
@@ -72,10 +67,138 @@ func (strategy *LeakyBucketStrategy) RetryAfter(clientId string) time.Duration {
 		return 0
 	}
 
+	strategy.leak(state, now)
+	return strategy.retryAfter(state)
+}
+
+// Clients returns the ids of all tracked clients, sorted.
+func (strategy *LeakyBucketStrategy) Clients() []string {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	ids := make([]string, 0, len(strategy.clients))
+	for id := range strategy.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Inspect reports the depth of the client's queue.
+func (strategy *LeakyBucketStrategy) Inspect(clientId string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	result := ClientState{
+		ClientId:  clientId,
+		Limit:     strategy.BucketSize,
+		Remaining: math.Ceil(strategy.BucketSize),
+	}
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		return result, false
+	}
+
+	strategy.leak(state, now)
+	result.Queued = state.QueuedRequests
+	result.Remaining = math.Max(0, math.Ceil(strategy.BucketSize-state.QueuedRequests))
+	result.RetryAfter = strategy.retryAfter(state)
+	return result, true
+}
+
+// Reset forgets the client's queue.
+func (strategy *LeakyBucketStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	delete(strategy.clients, clientId)
+}
+
+// Charge queues n requests for the client, up to BucketSize.
+func (strategy *LeakyBucketStrategy) Charge(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state := strategy.state(clientId, now)
+	state.QueuedRequests = math.Min(strategy.BucketSize, state.QueuedRequests+float64(max(0, n)))
+}
+
+// Refund drains n requests from the client's queue, down to empty.
+func (strategy *LeakyBucketStrategy) Refund(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		return
+	}
+	strategy.leak(state, now)
+	state.QueuedRequests = math.Max(0, state.QueuedRequests-float64(max(0, n)))
+}
+
+// Snapshot writes the queues of all clients to w.
+func (strategy *LeakyBucketStrategy) Snapshot(w io.Writer) error {
+	strategy.mutex.Lock()
+	clients := make(map[string]leakyBucketState, len(strategy.clients))
+	for id, state := range strategy.clients {
+		clients[id] = *state
+	}
+	strategy.mutex.Unlock()
+
+	return writeSnapshot(w, "leaky-bucket", clockNow(strategy.Clock), clients)
+}
+
+// Restore replaces all queues with those read from r. Queues keep leaking for
+// the time the snapshot was stored; those that are empty by now are dropped.
+func (strategy *LeakyBucketStrategy) Restore(r io.Reader) error {
+	clients, err := readSnapshot[leakyBucketState](r, "leaky-bucket")
+	if err != nil {
+		return err
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.clients = make(map[string]*leakyBucketState, len(clients))
+	for id, state := range clients {
+		state.LastLeak = notAfter(state.LastLeak, now)
+		strategy.leak(&state, now)
+		if state.QueuedRequests <= 0 {
+			continue
+		}
+		strategy.clients[id] = &state
+	}
+	return nil
+}
+
+// state returns the client's leaked bucket, creating an empty one if needed.
+// The caller must hold the mutex.
+func (strategy *LeakyBucketStrategy) state(clientId string, now time.Time) *leakyBucketState {
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		state = &leakyBucketState{QueuedRequests: 0, LastLeak: now}
+		strategy.clients[clientId] = state
+	}
+
+	strategy.leak(state, now)
+	return state
+}
+
+// leak must be called with the mutex held.
+func (strategy *LeakyBucketStrategy) leak(state *leakyBucketState, now time.Time) {
 	elapsed := now.Sub(state.LastLeak).Seconds()
-	state.QueuedRequests = math.Max(0, state.QueuedRequests-(elapsed*strategy.LeakRate))
+	leaked := elapsed * strategy.LeakRate
+	state.QueuedRequests = math.Max(0, state.QueuedRequests-leaked)
 	state.LastLeak = now
+}
 
+// retryAfter must be called with the mutex held.
+func (strategy *LeakyBucketStrategy) retryAfter(state *leakyBucketState) time.Duration {
 	if state.QueuedRequests < strategy.BucketSize {
 		return 0
 	}
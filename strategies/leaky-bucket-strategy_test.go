@@ -142,3 +142,44 @@ func TestRetryAfter_LeakyBucket(t *testing.T) {
 		t.Fatal("expected zero retry-after after leak")
 	}
 }
+
+// Inspect, Charge and Reset should observe and manipulate the queue depth.
+func TestInspectChargeReset_LeakyBucket(t *testing.T) {
+	s := NewLeakyBucketStrategy(0, 2)
+	client := "userA"
+
+	s.Charge(client, 5)
+	state, tracked := s.Inspect(client)
+	if !tracked || state.Queued != 2 || state.Remaining != 0 {
+		t.Fatalf("after charge: unexpected state %+v", state)
+	}
+
+	s.Reset(client)
+	if !s.IsRequestAllowed(client) {
+		t.Fatal("expected allowed after reset")
+	}
+}
+
+// Concurrent refunds should drain the queue without going below empty.
+func TestRefund_LeakyBucket(t *testing.T) {
+	bucketSize := 50.0
+	s := NewLeakyBucketStrategy(0, bucketSize)
+	client := "userA"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.IsRequestAllowed(client) {
+				s.Refund(client, 1)
+			}
+			s.Refund(client, 1)
+		}()
+	}
+	wg.Wait()
+
+	if state, _ := s.Inspect(client); state.Queued != 0 || state.Remaining != bucketSize {
+		t.Fatalf("expected empty queue, got %+v", state)
+	}
+}
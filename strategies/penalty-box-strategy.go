@@ -0,0 +1,321 @@
+package strategies
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+type PenaltyBoxStrategy struct {
+	Strategy  RateLimitStrategy
+	Threshold int
+	Period    time.Duration
+	BaseBan   time.Duration
+	MaxBan    time.Duration
+	// OnBanStart, if set, is called when a client is banned.
+	OnBanStart func(clientId string, duration time.Duration)
+	// OnBanEnd, if set, is called once when a client's ban expires, from a
+	// timer goroutine, or on the client's next request or retry-after lookup
+	// if that comes first. With a Clock set, the timer still runs on the
+	// system clock and only fires once the Clock has passed the ban's end.
+	OnBanEnd func(clientId string)
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string]*penaltyBoxState
+	mutex   sync.Mutex
+}
+
+type penaltyBoxState struct {
+	Violations  int       `json:"violations"`
+	PeriodStart time.Time `json:"periodStart"`
+	Bans        int       `json:"bans"`
+	BannedUntil time.Time `json:"bannedUntil"`
+	LastBanEnd  time.Time `json:"lastBanEnd"`
+	// timer reports the end of the current ban to OnBanEnd.
+	timer *time.Timer
+}
+
+// NewPenaltyBoxStrategy wraps a strategy with temporary bans for repeat offenders.
+//
+// Parameters:
+//   - strategy: the underlying strategy whose denials count as violations.
+//   - threshold: number of denials within period that triggers a ban.
+//   - period: duration of the window in which denials are counted.
+//   - baseBan: length of the first ban.
+//   - maxBan: upper bound for the ban length.
+//
+// Returns:
+//   - *PenaltyBoxStrategy: a pointer to a new instance of the strategy.
+//
+// While banned, every request is denied without consulting the underlying
+// strategy and RetryAfter reports the remaining ban. Each consecutive ban
+// doubles in length up to maxBan; the escalation is forgotten once a client
+// stays clean for maxBan after its last ban.
+func NewPenaltyBoxStrategy(strategy RateLimitStrategy, threshold int, period, baseBan, maxBan time.Duration) *PenaltyBoxStrategy {
+	return &PenaltyBoxStrategy{
+		Strategy:  strategy,
+		Threshold: threshold,
+		Period:    period,
+		BaseBan:   baseBan,
+		MaxBan:    maxBan,
+		clients:   make(map[string]*penaltyBoxState),
+	}
+}
+
+func (strategy *PenaltyBoxStrategy) IsRequestAllowed(clientId string) bool {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	state, banEnded := strategy.state(clientId, now)
+	banned := now.Before(state.BannedUntil)
+	strategy.mutex.Unlock()
+
+	allowed := false
+	var ban time.Duration
+	if !banned {
+		// The underlying strategy is consulted without the mutex, so clients
+		// are not serialized through it.
+		allowed = strategy.Strategy.IsRequestAllowed(clientId)
+	}
+	if !banned && !allowed {
+		strategy.mutex.Lock()
+		state, ended := strategy.state(clientId, now)
+		banEnded = banEnded || ended
+		// Another request may have started a ban in the meantime.
+		if !now.Before(state.BannedUntil) {
+			ban = strategy.recordViolation(clientId, state, now)
+		}
+		strategy.mutex.Unlock()
+	}
+
+	if banEnded && strategy.OnBanEnd != nil {
+		strategy.OnBanEnd(clientId)
+	}
+	if ban > 0 && strategy.OnBanStart != nil {
+		strategy.OnBanStart(clientId, ban)
+	}
+	return allowed
+}
+
+// RetryAfter returns the remaining ban, or defers to the underlying strategy
+// when the client is not banned.
+func (strategy *PenaltyBoxStrategy) RetryAfter(clientId string) time.Duration {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+
+	var wait time.Duration
+	state, banEnded := strategy.state(clientId, now)
+	if now.Before(state.BannedUntil) {
+		wait = state.BannedUntil.Sub(now)
+	}
+	strategy.mutex.Unlock()
+
+	if banEnded && strategy.OnBanEnd != nil {
+		strategy.OnBanEnd(clientId)
+	}
+	if wait > 0 {
+		return wait
+	}
+	return strategy.Strategy.RetryAfter(clientId)
+}
+
+// Clients returns the ids tracked by the penalty box or the underlying
+// strategy, sorted.
+func (strategy *PenaltyBoxStrategy) Clients() []string {
+	strategy.mutex.Lock()
+	seen := make(map[string]struct{}, len(strategy.clients))
+	for id := range strategy.clients {
+		seen[id] = struct{}{}
+	}
+	strategy.mutex.Unlock()
+
+	if inspector, ok := strategy.Strategy.(Inspector); ok {
+		for _, id := range inspector.Clients() {
+			seen[id] = struct{}{}
+		}
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Inspect reports the underlying strategy's state, overriding RetryAfter and
+// Remaining while the client is banned.
+func (strategy *PenaltyBoxStrategy) Inspect(clientId string) (ClientState, bool) {
+	result, tracked := ClientState{ClientId: clientId}, false
+	if inspector, ok := strategy.Strategy.(Inspector); ok {
+		result, tracked = inspector.Inspect(clientId)
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	if state, exists := strategy.clients[clientId]; exists {
+		tracked = true
+		if now.Before(state.BannedUntil) {
+			result.Remaining = 0
+			result.RetryAfter = state.BannedUntil.Sub(now)
+		}
+	}
+	return result, tracked
+}
+
+// Reset lifts any ban, forgets the client's violations and resets the
+// underlying strategy if it supports it.
+func (strategy *PenaltyBoxStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	if state, exists := strategy.clients[clientId]; exists && state.timer != nil {
+		state.timer.Stop()
+	}
+	delete(strategy.clients, clientId)
+	strategy.mutex.Unlock()
+
+	if resetter, ok := strategy.Strategy.(Resetter); ok {
+		resetter.Reset(clientId)
+	}
+}
+
+// Charge forwards to the underlying strategy if it supports it.
+func (strategy *PenaltyBoxStrategy) Charge(clientId string, n int) {
+	if resetter, ok := strategy.Strategy.(Resetter); ok {
+		resetter.Charge(clientId, n)
+	}
+}
+
+// Refund forwards to the underlying strategy if it supports it. Violations
+// already recorded are not refunded.
+func (strategy *PenaltyBoxStrategy) Refund(clientId string, n int) {
+	if refunder, ok := strategy.Strategy.(Refunder); ok {
+		refunder.Refund(clientId, n)
+	}
+}
+
+// Snapshot writes the bans and violations of all clients to w. The underlying
+// strategy is not included; snapshot it separately if it supports it.
+func (strategy *PenaltyBoxStrategy) Snapshot(w io.Writer) error {
+	strategy.mutex.Lock()
+	clients := make(map[string]penaltyBoxState, len(strategy.clients))
+	for id, state := range strategy.clients {
+		clients[id] = *state
+	}
+	strategy.mutex.Unlock()
+
+	return writeSnapshot(w, "penalty-box", clockNow(strategy.Clock), clients)
+}
+
+// Restore replaces all bans and violations with those read from r. Bans run
+// on through the downtime, bans longer than MaxBan are shortened to it, and
+// clients with nothing left to remember are dropped.
+func (strategy *PenaltyBoxStrategy) Restore(r io.Reader) error {
+	clients, err := readSnapshot[penaltyBoxState](r, "penalty-box")
+	if err != nil {
+		return err
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	for _, state := range strategy.clients {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+	}
+	strategy.clients = make(map[string]*penaltyBoxState, len(clients))
+	for id, state := range clients {
+		state.PeriodStart = notAfter(state.PeriodStart, now)
+		state.LastBanEnd = notAfter(state.LastBanEnd, now)
+		if limit := now.Add(strategy.MaxBan); state.BannedUntil.After(limit) {
+			state.BannedUntil = limit
+		}
+		strategy.clients[id] = &state
+		restored, _ := strategy.state(id, now)
+		if restored.Violations == 0 && restored.Bans == 0 {
+			delete(strategy.clients, id)
+			continue
+		}
+		if now.Before(restored.BannedUntil) {
+			strategy.schedule(id, restored, restored.BannedUntil.Sub(now))
+		}
+	}
+	return nil
+}
+
+// state returns the client's penalty state and whether a ban has just
+// expired. The caller must hold the mutex.
+func (strategy *PenaltyBoxStrategy) state(clientId string, now time.Time) (*penaltyBoxState, bool) {
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		state = &penaltyBoxState{PeriodStart: now}
+		strategy.clients[clientId] = state
+	}
+
+	banEnded := endBan(state, now)
+	if state.Bans > 0 && state.BannedUntil.IsZero() && now.Sub(state.LastBanEnd) >= strategy.MaxBan {
+		state.Bans = 0
+	}
+	if now.Sub(state.PeriodStart) >= strategy.Period {
+		state.PeriodStart = now
+		state.Violations = 0
+	}
+
+	return state, banEnded
+}
+
+// endBan clears a ban that has expired and reports whether it did. The caller
+// must hold the mutex.
+func endBan(state *penaltyBoxState, now time.Time) bool {
+	if state.BannedUntil.IsZero() || now.Before(state.BannedUntil) {
+		return false
+	}
+	state.LastBanEnd = state.BannedUntil
+	state.BannedUntil = time.Time{}
+	return true
+}
+
+// schedule starts a timer that ends the client's ban after d, so OnBanEnd
+// fires even if the client never returns. The caller must hold the mutex.
+func (strategy *PenaltyBoxStrategy) schedule(clientId string, state *penaltyBoxState, d time.Duration) {
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	state.timer = time.AfterFunc(d, func() {
+		strategy.mutex.Lock()
+		banEnded := false
+		if current, exists := strategy.clients[clientId]; exists && current == state {
+			banEnded = endBan(state, clockNow(strategy.Clock))
+		}
+		strategy.mutex.Unlock()
+
+		if banEnded && strategy.OnBanEnd != nil {
+			strategy.OnBanEnd(clientId)
+		}
+	})
+}
+
+// recordViolation counts a denial and returns the ban length if it starts a
+// ban. The caller must hold the mutex.
+func (strategy *PenaltyBoxStrategy) recordViolation(clientId string, state *penaltyBoxState, now time.Time) time.Duration {
+	state.Violations++
+	if state.Violations < strategy.Threshold {
+		return 0
+	}
+
+	ban := strategy.BaseBan
+	for i := 0; i < state.Bans && ban < strategy.MaxBan; i++ {
+		ban *= 2
+	}
+	ban = min(ban, strategy.MaxBan)
+
+	state.Bans++
+	state.Violations = 0
+	state.PeriodStart = now
+	state.BannedUntil = now.Add(ban)
+	strategy.schedule(clientId, state, ban)
+	return ban
+}
@@ -0,0 +1,187 @@
+package strategies
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Repeated denials within the period should trigger a ban reported by RetryAfter.
+func TestPenaltyBoxBansAfterThreshold(t *testing.T) {
+	inner := NewFixedWindowStrategy(1, 20*time.Millisecond)
+	s := NewPenaltyBoxStrategy(inner, 2, time.Second, 200*time.Millisecond, time.Second)
+	client := "userA"
+
+	var started []time.Duration
+	s.OnBanStart = func(clientId string, d time.Duration) { started = append(started, d) }
+
+	if !s.IsRequestAllowed(client) {
+		t.Fatal("first request should be allowed")
+	}
+	_ = s.IsRequestAllowed(client) // violation 1
+	_ = s.IsRequestAllowed(client) // violation 2 → ban
+	if len(started) != 1 || started[0] != 200*time.Millisecond {
+		t.Fatalf("expected one 200ms ban, got %v", started)
+	}
+
+	// the inner window rolls over, but the ban still applies
+	time.Sleep(30 * time.Millisecond)
+	if s.IsRequestAllowed(client) {
+		t.Fatal("banned client should be denied")
+	}
+	if wait := s.RetryAfter(client); wait < 100*time.Millisecond {
+		t.Fatalf("expected retry-after close to ban length, got %v", wait)
+	}
+}
+
+// Each consecutive ban doubles up to MaxBan and OnBanEnd fires once a ban expires.
+func TestPenaltyBoxEscalatesAndEnds(t *testing.T) {
+	inner := NewTokenBucketStrategy(0, 0)
+	s := NewPenaltyBoxStrategy(inner, 1, time.Second, 20*time.Millisecond, 50*time.Millisecond)
+	client := "userA"
+
+	var mu sync.Mutex
+	var started []time.Duration
+	ended := 0
+	s.OnBanStart = func(clientId string, d time.Duration) {
+		mu.Lock()
+		started = append(started, d)
+		mu.Unlock()
+	}
+	s.OnBanEnd = func(clientId string) {
+		mu.Lock()
+		ended++
+		mu.Unlock()
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = s.IsRequestAllowed(client) // denied by inner → ban
+		time.Sleep(s.RetryAfter(client) + 5*time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}
+	if len(started) != len(want) {
+		t.Fatalf("expected %d bans, got %v", len(want), started)
+	}
+	for i := range want {
+		if started[i] != want[i] {
+			t.Fatalf("ban %d: expected %v, got %v", i+1, want[i], started[i])
+		}
+	}
+	if s.RetryAfter(client) != 0 {
+		t.Fatal("expected no ban after sleeping it off")
+	}
+	if ended != 3 {
+		t.Fatalf("expected 3 ban-end events, got %d", ended)
+	}
+}
+
+// OnBanEnd fires when the ban expires even if the client never returns.
+func TestPenaltyBoxBanEndsWithoutRequests(t *testing.T) {
+	s := NewPenaltyBoxStrategy(NewTokenBucketStrategy(0, 0), 1, time.Second, 20*time.Millisecond, time.Second)
+	ended := make(chan string, 2)
+	s.OnBanEnd = func(clientId string) { ended <- clientId }
+
+	_ = s.IsRequestAllowed("userA") // ban
+	select {
+	case clientId := <-ended:
+		if clientId != "userA" {
+			t.Fatalf("expected userA's ban to end, got %q", clientId)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected OnBanEnd without further requests")
+	}
+
+	// the lazy path must not report the same ban again
+	_ = s.RetryAfter("userA")
+	if len(ended) != 0 {
+		t.Fatal("expected a single ban-end event")
+	}
+}
+
+// The underlying strategy is consulted without holding the penalty box's
+// mutex, so one slow client does not block the others.
+func TestPenaltyBoxDoesNotHoldMutexWhileDelegating(t *testing.T) {
+	inner := &blockingStrategy{entered: make(chan struct{}), release: make(chan struct{})}
+	s := NewPenaltyBoxStrategy(inner, 1, time.Second, time.Second, time.Second)
+
+	go s.IsRequestAllowed("slow")
+	<-inner.entered
+	done := make(chan struct{})
+	go func() {
+		s.RetryAfter("other")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected other clients not to wait for the underlying strategy")
+	}
+	close(inner.release)
+}
+
+// blockingStrategy blocks in IsRequestAllowed until released.
+type blockingStrategy struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (strategy *blockingStrategy) IsRequestAllowed(string) bool {
+	close(strategy.entered)
+	<-strategy.release
+	return true
+}
+
+func (strategy *blockingStrategy) RetryAfter(string) time.Duration { return 0 }
+
+// Reset should lift an active ban.
+func TestPenaltyBoxResetLiftsBan(t *testing.T) {
+	inner := NewFixedWindowStrategy(1, time.Minute)
+	s := NewPenaltyBoxStrategy(inner, 1, time.Minute, time.Minute, time.Hour)
+	client := "userA"
+
+	_ = s.IsRequestAllowed(client)
+	_ = s.IsRequestAllowed(client) // ban
+	if state, _ := s.Inspect(client); state.RetryAfter < 59*time.Second {
+		t.Fatalf("expected inspect to report the ban, got %+v", state)
+	}
+
+	s.Reset(client)
+	if !s.IsRequestAllowed(client) {
+		t.Fatal("expected allowed after reset")
+	}
+}
+
+// A restored penalty box should keep banned clients banned and remember how
+// often they were banned.
+func TestPenaltyBoxSnapshot(t *testing.T) {
+	s := NewPenaltyBoxStrategy(NewFixedWindowStrategy(1, time.Minute), 1, time.Minute, time.Minute, time.Hour)
+	_ = s.IsRequestAllowed("userA")
+	_ = s.IsRequestAllowed("userA") // ban
+	_ = s.IsRequestAllowed("userB")
+
+	var buf bytes.Buffer
+	if err := s.Snapshot(&buf); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	restored := NewPenaltyBoxStrategy(NewFixedWindowStrategy(1, time.Minute), 1, time.Minute, time.Minute, time.Hour)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if restored.IsRequestAllowed("userA") {
+		t.Fatal("restored ban should still deny the client")
+	}
+	if wait := restored.RetryAfter("userA"); wait < 59*time.Second || wait > time.Minute {
+		t.Fatalf("expected the remaining ban, got %v", wait)
+	}
+	if state := restored.clients["userA"]; state.Bans != 1 {
+		t.Fatalf("expected the ban count to survive, got %+v", state)
+	}
+	if _, exists := restored.clients["userB"]; exists {
+		t.Fatal("client without violations or bans should not be restored")
+	}
+}
@@ -0,0 +1,272 @@
+package strategies
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// PriorityClass is a class of traffic and its share of the global capacity.
+type PriorityClass struct {
+	Name string
+	// Share is the class's weight; shares are normalized to sum to 1.
+	Share float64
+}
+
+type PriorityStrategy struct {
+	// Classes are ordered from highest to lowest priority.
+	Classes []PriorityClass
+	// Rate is the global capacity in requests per second.
+	Rate float64
+	// Burst is the global number of requests that can be admitted at once.
+	Burst float64
+	// Reserve is the fraction of each class's share of Burst that lower
+	// classes may not borrow.
+	Reserve float64
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	buckets []*tokenBucketState
+	// charged holds, per class, the buckets its latest admissions took tokens
+	// from, most recent last, so refunds go back where they came from.
+	charged [][]int
+	index   map[string]int
+	mutex   sync.Mutex
+}
+
+// NewPriorityStrategy creates a global, class-aware limiter for load shedding.
+//
+// Parameters:
+//   - rate: global capacity in requests per second.
+//   - burst: global number of requests that can be admitted at once.
+//   - classes: the priority classes, from highest to lowest priority.
+//
+// Returns:
+//   - *PriorityStrategy: a pointer to a new instance of the strategy.
+//
+// The strategy is keyed by class name rather than by client. Each class gets a
+// token bucket holding its share of rate and burst. A class that has used up
+// its own bucket may borrow unused tokens from higher-priority classes, nearest
+// first, but only those above the lender's Reserve, which defaults to half its
+// bucket. A class that stays within its share of rate and bursts no more than
+// its reserve is therefore never shed, and under overload the lowest classes
+// are shed first. Unknown class names are treated as the lowest class. It
+// panics if no classes are given, a name repeats or a share is not positive.
+func NewPriorityStrategy(rate, burst float64, classes ...PriorityClass) *PriorityStrategy {
+	if len(classes) == 0 {
+		panic("strategies: priority strategy requires at least one class")
+	}
+
+	strategy := &PriorityStrategy{
+		Classes: classes,
+		Rate:    rate,
+		Burst:   burst,
+		Reserve: 0.5,
+		buckets: make([]*tokenBucketState, len(classes)),
+		charged: make([][]int, len(classes)),
+		index:   make(map[string]int, len(classes)),
+	}
+	for i, class := range classes {
+		if class.Share <= 0 {
+			panic("strategies: priority class share must be positive")
+		}
+		if _, exists := strategy.index[class.Name]; exists {
+			panic("strategies: duplicate priority class " + class.Name)
+		}
+		strategy.index[class.Name] = i
+	}
+	return strategy
+}
+
+func (strategy *PriorityStrategy) IsRequestAllowed(class string) bool {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	i := strategy.classIndex(class)
+	for _, j := range strategy.lenders(i) {
+		if strategy.available(i, j, now) >= 1 {
+			strategy.bucket(j, now).Tokens--
+			strategy.record(i, j)
+			return true
+		}
+	}
+	return false
+}
+
+// RetryAfter returns how long until the class has a token or a higher one has
+// one to lend.
+func (strategy *PriorityStrategy) RetryAfter(class string) time.Duration {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	return strategy.wait(strategy.classIndex(class), now)
+}
+
+// Clients returns the class names, from highest to lowest priority.
+func (strategy *PriorityStrategy) Clients() []string {
+	names := make([]string, len(strategy.Classes))
+	for i, class := range strategy.Classes {
+		names[i] = class.Name
+	}
+	return names
+}
+
+// Inspect reports the tokens in the class's own bucket. Remaining also counts
+// the tokens the class could borrow from higher classes.
+func (strategy *PriorityStrategy) Inspect(class string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	_, known := strategy.index[class]
+	i := strategy.classIndex(class)
+	result := ClientState{
+		ClientId: class,
+		Limit:    strategy.Burst * strategy.share(i),
+		Tokens:   strategy.bucket(i, now).Tokens,
+	}
+	for _, j := range strategy.lenders(i) {
+		result.Remaining += math.Floor(math.Max(0, strategy.available(i, j, now)))
+	}
+	result.RetryAfter = strategy.wait(i, now)
+	return result, known
+}
+
+// Reset refills the class's own bucket.
+func (strategy *PriorityStrategy) Reset(class string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	i := strategy.classIndex(class)
+	strategy.buckets[i] = nil
+	strategy.charged[i] = nil
+}
+
+// Charge removes n tokens from the class's own bucket, down to empty.
+func (strategy *PriorityStrategy) Charge(class string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	bucket := strategy.bucket(strategy.classIndex(class), now)
+	bucket.Tokens = math.Max(0, bucket.Tokens-float64(max(0, n)))
+}
+
+// Refund puts n tokens back into the buckets the class's latest admissions
+// took them from, each up to its share of Burst. Tokens beyond those it
+// remembers go to the class's own bucket.
+func (strategy *PriorityStrategy) Refund(class string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	i := strategy.classIndex(class)
+	for ; n > 0; n-- {
+		j := i
+		if charged := strategy.charged[i]; len(charged) > 0 {
+			j = charged[len(charged)-1]
+			strategy.charged[i] = charged[:len(charged)-1]
+		}
+		bucket := strategy.bucket(j, now)
+		bucket.Tokens = math.Min(strategy.Burst*strategy.share(j), bucket.Tokens+1)
+	}
+}
+
+// classIndex returns the index of class, or the lowest class if unknown.
+func (strategy *PriorityStrategy) classIndex(class string) int {
+	if i, exists := strategy.index[class]; exists {
+		return i
+	}
+	return len(strategy.Classes) - 1
+}
+
+// lenders returns the buckets class i may take tokens from, in order: its own,
+// then those of higher classes, nearest first.
+func (strategy *PriorityStrategy) lenders(i int) []int {
+	lenders := []int{i}
+	for j := i - 1; j >= 0; j-- {
+		lenders = append(lenders, j)
+	}
+	return lenders
+}
+
+// available returns the tokens class i may take from bucket j: all of its
+// own, or those above the reserve of a higher class. The caller must hold the
+// mutex.
+func (strategy *PriorityStrategy) available(i, j int, now time.Time) float64 {
+	return strategy.bucket(j, now).Tokens - strategy.reserve(i, j)
+}
+
+// reserve returns the tokens bucket j keeps from class i.
+func (strategy *PriorityStrategy) reserve(i, j int) float64 {
+	if i == j {
+		return 0
+	}
+	return strategy.Burst * strategy.share(j) * math.Min(1, math.Max(0, strategy.Reserve))
+}
+
+// record remembers that class i took a token from bucket j, keeping at most
+// as many entries as Burst. The caller must hold the mutex.
+func (strategy *PriorityStrategy) record(i, j int) {
+	charged := append(strategy.charged[i], j)
+	if limit := max(1, int(strategy.Burst)); len(charged) > limit {
+		charged = charged[len(charged)-limit:]
+	}
+	strategy.charged[i] = charged
+}
+
+// share returns the normalized share of class i.
+func (strategy *PriorityStrategy) share(i int) float64 {
+	total := 0.0
+	for _, class := range strategy.Classes {
+		total += class.Share
+	}
+	return strategy.Classes[i].Share / total
+}
+
+// bucket returns the refilled bucket of class i, creating a full one if
+// needed. The caller must hold the mutex.
+func (strategy *PriorityStrategy) bucket(i int, now time.Time) *tokenBucketState {
+	share := strategy.share(i)
+	size := strategy.Burst * share
+	bucket := strategy.buckets[i]
+	if bucket == nil {
+		bucket = &tokenBucketState{Tokens: size, LastRefill: now}
+		strategy.buckets[i] = bucket
+	}
+
+	elapsed := now.Sub(bucket.LastRefill).Seconds()
+	bucket.Tokens = math.Min(size, bucket.Tokens+elapsed*strategy.Rate*share)
+	bucket.LastRefill = now
+	return bucket
+}
+
+// wait returns how long until class i may take a token from its own bucket or
+// a higher class's, or zero if it never will. The caller must hold the mutex.
+func (strategy *PriorityStrategy) wait(i int, now time.Time) time.Duration {
+	wait := time.Duration(math.MaxInt64)
+	for _, j := range strategy.lenders(i) {
+		wait = min(wait, strategy.retryAfter(j, strategy.reserve(i, j)+1, now))
+	}
+	if wait == math.MaxInt64 {
+		return 0
+	}
+	return wait
+}
+
+// retryAfter returns how long until bucket i holds the given number of
+// tokens, or math.MaxInt64 if it cannot hold them. The caller must hold the
+// mutex.
+func (strategy *PriorityStrategy) retryAfter(i int, tokens float64, now time.Time) time.Duration {
+	bucket := strategy.bucket(i, now)
+	if bucket.Tokens >= tokens {
+		return 0
+	}
+	rate := strategy.Rate * strategy.share(i)
+	if rate <= 0 || strategy.Burst*strategy.share(i) < tokens {
+		return time.Duration(math.MaxInt64)
+	}
+	seconds := (tokens - bucket.Tokens) / rate
+	return time.Duration(math.Ceil(seconds*1000)) * time.Millisecond
+}
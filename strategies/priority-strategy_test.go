@@ -0,0 +1,138 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+	"github.com/gabisonia/fiber-rate-limiter/strategies/strategytest"
+)
+
+func newPriority() (*strategies.PriorityStrategy, *strategytest.FakeClock) {
+	clock := strategytest.NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := strategies.NewPriorityStrategy(10, 10,
+		strategies.PriorityClass{Name: "critical", Share: 5},
+		strategies.PriorityClass{Name: "normal", Share: 3},
+		strategies.PriorityClass{Name: "batch", Share: 2},
+	)
+	strategy.Clock = clock
+	return strategy, clock
+}
+
+func admitted(strategy strategies.RateLimitStrategy, class string, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if strategy.IsRequestAllowed(class) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestPriorityLowerClassesBorrowFromHigher(t *testing.T) {
+	strategy, _ := newPriority()
+
+	// batch has 2 tokens of its own and may borrow what normal and critical
+	// hold above their reserves of 1.5 and 2.5.
+	if got := admitted(strategy, "batch", 20); got != 5 {
+		t.Fatalf("expected batch to borrow idle capacity from higher classes, got %d", got)
+	}
+	if got := admitted(strategy, "critical", 20); got != 3 {
+		t.Fatalf("expected critical to keep its reserve, got %d", got)
+	}
+	if got := admitted(strategy, "normal", 20); got != 2 {
+		t.Fatalf("expected normal to keep its reserve, got %d", got)
+	}
+}
+
+func TestPriorityHigherClassesNeverBorrowFromLower(t *testing.T) {
+	strategy, _ := newPriority()
+
+	if got := admitted(strategy, "critical", 20); got != 5 {
+		t.Fatalf("expected critical to be limited to its own 5 tokens, got %d", got)
+	}
+	if got := admitted(strategy, "batch", 20); got != 3 {
+		t.Fatalf("expected batch to get its own and normal's spare tokens, got %d", got)
+	}
+	if retry := strategy.RetryAfter("critical"); retry != 200*time.Millisecond {
+		t.Fatalf("expected critical to refill a token in 200ms, got %s", retry)
+	}
+}
+
+func TestPriorityZeroReserveLendsEverything(t *testing.T) {
+	strategy, _ := newPriority()
+	strategy.Reserve = 0
+
+	if got := admitted(strategy, "batch", 20); got != 10 {
+		t.Fatalf("expected batch to use all 10 idle tokens, got %d", got)
+	}
+}
+
+// Batch floods the limiter while critical stays under its share of 5 per
+// second; critical must never be shed, and batch gets the capacity left idle.
+func TestPriorityShedsLowestClassFirst(t *testing.T) {
+	strategy, clock := newPriority()
+
+	critical, batch := 0, 0
+	for tick := 0; tick < 200; tick++ {
+		batch += admitted(strategy, "batch", 5)
+		if tick%5 == 0 && strategy.IsRequestAllowed("critical") {
+			critical++
+		}
+		clock.Advance(50 * time.Millisecond)
+	}
+
+	if critical != 40 {
+		t.Fatalf("expected all 40 critical requests to be admitted, got %d", critical)
+	}
+	if batch < 50 || batch > 66 {
+		t.Fatalf("expected batch to get the idle 6 per second, got %d in 10s", batch)
+	}
+	if state, _ := strategy.Inspect("batch"); state.Remaining != 0 || state.RetryAfter == 0 {
+		t.Fatalf("expected batch to wait for a token, got %+v", state)
+	}
+	if got := admitted(strategy, "critical", 2); got != 2 {
+		t.Fatalf("expected critical to burst into its reserve, got %d", got)
+	}
+}
+
+func TestPriorityUnknownClassIsLowest(t *testing.T) {
+	strategy, _ := newPriority()
+	if got := admitted(strategy, "unknown", 20); got != 5 {
+		t.Fatalf("expected unknown to behave like batch, got %d", got)
+	}
+	if _, known := strategy.Inspect("unknown"); known {
+		t.Fatal("expected unknown class to be reported as untracked")
+	}
+}
+
+func TestPriorityRefundReturnsBorrowedTokens(t *testing.T) {
+	strategy, _ := newPriority()
+
+	// batch takes its own 2, then one of normal's and two of critical's.
+	admitted(strategy, "batch", 5)
+	strategy.Refund("batch", 3)
+	for class, want := range map[string]float64{"critical": 5, "normal": 3, "batch": 0} {
+		if state, _ := strategy.Inspect(class); state.Tokens != want {
+			t.Fatalf("expected %s to get back %v tokens, got %v", class, want, state.Tokens)
+		}
+	}
+
+	strategy.Refund("batch", 10)
+	if state, _ := strategy.Inspect("batch"); state.Tokens != 2 {
+		t.Fatalf("expected refund to be capped at batch's 2 tokens, got %v", state.Tokens)
+	}
+}
+
+func TestPriorityResetAndCharge(t *testing.T) {
+	strategy, _ := newPriority()
+
+	strategy.Charge("normal", 2)
+	if state, _ := strategy.Inspect("normal"); state.Tokens != 1 {
+		t.Fatalf("expected normal to have 1 token after charging 2, got %v", state.Tokens)
+	}
+	strategy.Reset("normal")
+	if state, _ := strategy.Inspect("normal"); state.Tokens != 3 {
+		t.Fatalf("expected reset to refill normal's 3 tokens, got %v", state.Tokens)
+	}
+}
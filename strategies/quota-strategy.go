@@ -0,0 +1,264 @@
+package strategies
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QuotaPeriod is the calendar unit a QuotaStrategy resets on.
+type QuotaPeriod int
+
+const (
+	// Hourly quotas reset at the top of every hour.
+	Hourly QuotaPeriod = iota
+	// Daily quotas reset at midnight.
+	Daily
+	// Weekly quotas reset at midnight between Sunday and Monday.
+	Weekly
+	// Monthly quotas reset at midnight on the first day of the month.
+	Monthly
+)
+
+type QuotaStrategy struct {
+	Limit    int
+	Period   QuotaPeriod
+	Location *time.Location
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string]*quotaState
+	mutex   sync.Mutex
+}
+
+type quotaState struct {
+	PeriodStart time.Time `json:"periodStart"`
+	Used        int       `json:"used"`
+}
+
+// QuotaUsage reports a client's consumption of its quota.
+type QuotaUsage struct {
+	Used      int
+	Remaining int
+	// ResetAt is when the current period ends and the quota is restored.
+	ResetAt time.Time
+}
+
+// NewQuotaStrategy creates a new calendar-aligned quota strategy.
+//
+// Parameters:
+//   - limit: maximum number of allowed requests per period.
+//   - period: the calendar unit the quota resets on, e.g. Daily or Monthly.
+//   - location: the time zone periods are aligned to; nil means UTC.
+//
+// Returns:
+//   - *QuotaStrategy: a pointer to a new instance of the strategy.
+//
+// Unlike FixedWindowStrategy, whose windows start at each client's first
+// request, every client's quota resets at the same calendar boundary, which
+// suits billing-style plans such as "10,000 requests per month".
+func NewQuotaStrategy(limit int, period QuotaPeriod, location *time.Location) *QuotaStrategy {
+	if location == nil {
+		location = time.UTC
+	}
+	return &QuotaStrategy{
+		Limit:    limit,
+		Period:   period,
+		Location: location,
+		clients:  make(map[string]*quotaState),
+	}
+}
+
+func (strategy *QuotaStrategy) IsRequestAllowed(clientId string) bool {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state := strategy.state(clientId, now)
+
+	if state.Used < strategy.Limit {
+		state.Used++
+		return true
+	}
+
+	return false
+}
+
+// RetryAfter returns the time until the current period ends if the quota is
+// exhausted.
+func (strategy *QuotaStrategy) RetryAfter(clientId string) time.Duration {
+	usage := strategy.Usage(clientId)
+	if usage.Remaining > 0 {
+		return 0
+	}
+	return max(0, usage.ResetAt.Sub(clockNow(strategy.Clock)))
+}
+
+// Usage reports how much of its quota the client has used in the current period.
+func (strategy *QuotaStrategy) Usage(clientId string) QuotaUsage {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	start, end := strategy.bounds(now)
+	usage := QuotaUsage{Remaining: strategy.Limit, ResetAt: end}
+	if state, exists := strategy.clients[clientId]; exists && state.PeriodStart.Equal(start) {
+		usage.Used = state.Used
+		usage.Remaining = max(0, strategy.Limit-state.Used)
+	}
+	return usage
+}
+
+// Clients returns the ids of all tracked clients, sorted.
+func (strategy *QuotaStrategy) Clients() []string {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	ids := make([]string, 0, len(strategy.clients))
+	for id := range strategy.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Inspect reports the client's usage in the current period.
+func (strategy *QuotaStrategy) Inspect(clientId string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	start, end := strategy.bounds(now)
+	result := ClientState{
+		ClientId:    clientId,
+		Limit:       float64(strategy.Limit),
+		Remaining:   float64(strategy.Limit),
+		WindowStart: start,
+	}
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		return result, false
+	}
+	if state.PeriodStart.Equal(start) {
+		result.Count = state.Used
+		result.Remaining = float64(max(0, strategy.Limit-state.Used))
+	}
+	if result.Remaining == 0 {
+		result.RetryAfter = end.Sub(now)
+	}
+	return result, true
+}
+
+// Reset restores the client's full quota.
+func (strategy *QuotaStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	delete(strategy.clients, clientId)
+}
+
+// Charge adds n requests to the client's usage, up to Limit.
+func (strategy *QuotaStrategy) Charge(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state := strategy.state(clientId, now)
+	state.Used = min(strategy.Limit, state.Used+max(0, n))
+}
+
+// Refund removes n requests from the client's usage in the current period.
+func (strategy *QuotaStrategy) Refund(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	if _, exists := strategy.clients[clientId]; !exists {
+		return
+	}
+	state := strategy.state(clientId, now)
+	state.Used = max(0, state.Used-max(0, n))
+}
+
+// Snapshot writes the usage of all clients to w.
+func (strategy *QuotaStrategy) Snapshot(w io.Writer) error {
+	strategy.mutex.Lock()
+	clients := make(map[string]quotaState, len(strategy.clients))
+	for id, state := range strategy.clients {
+		clients[id] = *state
+	}
+	strategy.mutex.Unlock()
+
+	return writeSnapshot(w, "quota", clockNow(strategy.Clock), clients)
+}
+
+// Restore replaces all usage with that read from r, dropping usage from
+// periods that have ended in the meantime.
+func (strategy *QuotaStrategy) Restore(r io.Reader) error {
+	clients, err := readSnapshot[quotaState](r, "quota")
+	if err != nil {
+		return err
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	start, _ := strategy.bounds(now)
+	strategy.clients = make(map[string]*quotaState, len(clients))
+	for id, state := range clients {
+		if !state.PeriodStart.Equal(start) {
+			continue
+		}
+		strategy.clients[id] = &state
+	}
+	return nil
+}
+
+// state returns the client's usage, creating or resetting it for the current
+// period. The caller must hold the mutex.
+func (strategy *QuotaStrategy) state(clientId string, now time.Time) *quotaState {
+	start, _ := strategy.bounds(now)
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		state = &quotaState{PeriodStart: start}
+		strategy.clients[clientId] = state
+	}
+
+	if !state.PeriodStart.Equal(start) {
+		state.PeriodStart = start
+		state.Used = 0
+	}
+
+	return state
+}
+
+// bounds returns the start and end of the period containing now. Days are
+// advanced with AddDate so periods stay aligned across DST changes. Hours are
+// found by subtracting from the instant rather than with time.Date, which is
+// ambiguous in the hour repeated when DST ends.
+func (strategy *QuotaStrategy) bounds(now time.Time) (time.Time, time.Time) {
+	local := now.In(strategy.Location)
+	year, month, day := local.Date()
+
+	switch strategy.Period {
+	case Hourly:
+		intoHour := time.Duration(local.Minute())*time.Minute +
+			time.Duration(local.Second())*time.Second +
+			time.Duration(local.Nanosecond())
+		start := local.Add(-intoHour)
+		return start, start.Add(time.Hour)
+	case Weekly:
+		offset := (int(local.Weekday()) + 6) % 7 // days since Monday
+		start := time.Date(year, month, day-offset, 0, 0, 0, 0, strategy.Location)
+		return start, start.AddDate(0, 0, 7)
+	case Monthly:
+		start := time.Date(year, month, 1, 0, 0, 0, 0, strategy.Location)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := time.Date(year, month, day, 0, 0, 0, 0, strategy.Location)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,114 @@
+package strategies
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Usage should count requests against the quota and deny once exhausted.
+func TestQuotaUsage(t *testing.T) {
+	s := NewQuotaStrategy(3, Daily, nil)
+	client := "userA"
+
+	for i := 0; i < 3; i++ {
+		if !s.IsRequestAllowed(client) {
+			t.Fatalf("request %d: expected allowed", i+1)
+		}
+	}
+	if s.IsRequestAllowed(client) {
+		t.Fatal("expected denied after quota exhausted")
+	}
+
+	usage := s.Usage(client)
+	if usage.Used != 3 || usage.Remaining != 0 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	if wait := s.RetryAfter(client); wait <= 0 || wait > 24*time.Hour {
+		t.Fatalf("expected retry-after until midnight, got %v", wait)
+	}
+
+	s.Refund(client, 1)
+	if usage := s.Usage(client); usage.Remaining != 1 {
+		t.Fatalf("expected one request back after refund, got %+v", usage)
+	}
+}
+
+// Period boundaries should follow the calendar in the configured time zone.
+func TestQuotaBoundsAreCalendarAligned(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	now := time.Date(2025, time.March, 9, 15, 30, 0, 0, newYork) // Sunday, DST starts at 2am
+
+	cases := []struct {
+		period     QuotaPeriod
+		start, end time.Time
+	}{
+		{Hourly, time.Date(2025, time.March, 9, 15, 0, 0, 0, newYork), time.Date(2025, time.March, 9, 16, 0, 0, 0, newYork)},
+		{Daily, time.Date(2025, time.March, 9, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 10, 0, 0, 0, 0, newYork)},
+		{Weekly, time.Date(2025, time.March, 3, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 10, 0, 0, 0, 0, newYork)},
+		{Monthly, time.Date(2025, time.March, 1, 0, 0, 0, 0, newYork), time.Date(2025, time.April, 1, 0, 0, 0, 0, newYork)},
+	}
+	for _, tc := range cases {
+		s := NewQuotaStrategy(1, tc.period, newYork)
+		start, end := s.bounds(now)
+		if !start.Equal(tc.start) || !end.Equal(tc.end) {
+			t.Errorf("period %d: expected [%v, %v), got [%v, %v)", tc.period, tc.start, tc.end, start, end)
+		}
+	}
+
+	// the DST day is only 23 hours long
+	s := NewQuotaStrategy(1, Daily, newYork)
+	if start, end := s.bounds(now); end.Sub(start) != 23*time.Hour {
+		t.Errorf("expected 23h day across DST change, got %v", end.Sub(start))
+	}
+}
+
+// fixedClock always reports the same time.
+type fixedClock time.Time
+
+func (clock fixedClock) Now() time.Time { return time.Time(clock) }
+
+// 01:30 occurs twice when DST ends; the second occurrence is in its own hour.
+func TestQuotaHourlyBoundsInRepeatedHour(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	now := time.Date(2025, time.November, 2, 6, 30, 0, 0, time.UTC) // 01:30 EST
+	s := NewQuotaStrategy(1, Hourly, newYork)
+	s.Clock = fixedClock(now)
+
+	start, end := s.bounds(now)
+	if want := time.Date(2025, time.November, 2, 6, 0, 0, 0, time.UTC); !start.Equal(want) || !end.Equal(want.Add(time.Hour)) {
+		t.Fatalf("expected [01:00 EST, 02:00 EST), got [%v, %v)", start, end)
+	}
+
+	s.IsRequestAllowed("u")
+	if s.IsRequestAllowed("u") {
+		t.Fatal("expected the quota to be used up")
+	}
+	if retry := s.RetryAfter("u"); retry != 30*time.Minute {
+		t.Fatalf("expected a retry at 02:00 EST in 30m, got %s", retry)
+	}
+}
+
+// Usage from the current period survives a snapshot round trip.
+func TestQuotaSnapshot(t *testing.T) {
+	s := NewQuotaStrategy(1, Monthly, time.UTC)
+	_ = s.IsRequestAllowed("userA")
+
+	var buf bytes.Buffer
+	if err := s.Snapshot(&buf); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	restored := NewQuotaStrategy(1, Monthly, time.UTC)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if restored.IsRequestAllowed("userA") {
+		t.Fatal("restored quota should still be exhausted")
+	}
+}
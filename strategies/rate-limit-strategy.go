@@ -9,3 +9,70 @@ type RateLimitStrategy interface {
 	// If zero, the request can be retried immediately.
 	RetryAfter(clientId string) time.Duration
 }
+
+// Inspector is implemented by strategies that can report per-client state.
+type Inspector interface {
+	// Clients returns the ids of all currently tracked clients, sorted.
+	Clients() []string
+	// Inspect returns the current state of clientId and whether it is tracked.
+	// Untracked clients are reported with their full allowance.
+	Inspect(clientId string) (ClientState, bool)
+}
+
+// Resetter is implemented by strategies whose per-client state can be modified.
+type Resetter interface {
+	// Reset forgets clientId, restoring its full allowance.
+	Reset(clientId string)
+	// Charge consumes n units of clientId's allowance without checking the
+	// limit. Usage never grows beyond the configured capacity.
+	Charge(clientId string, n int)
+}
+
+// Refunder is implemented by strategies that can give back units consumed by
+// admitted requests.
+type Refunder interface {
+	// Refund returns n units to clientId's allowance. The allowance never
+	// grows beyond the configured capacity, and untracked clients are ignored.
+	Refund(clientId string, n int)
+}
+
+// Weighted is implemented by strategies whose requests can cost more than one
+// unit, e.g. a token bucket denominated in bytes.
+type Weighted interface {
+	RateLimitStrategy
+	// IsRequestAllowedN consumes n units if they are all available.
+	IsRequestAllowedN(clientId string, n int) bool
+	// RetryAfterN returns how long until n units are available. It is zero if
+	// they are available now or n exceeds the capacity.
+	RetryAfterN(clientId string, n int) time.Duration
+	// Debit consumes n units without checking the limit. Unlike
+	// Resetter.Charge, the allowance may go into debt, and the client is
+	// denied until the debt is paid off.
+	Debit(clientId string, n int)
+}
+
+// Observer is implemented by strategies that adapt to how the protected
+// service performs. The middleware reports every admitted request.
+type Observer interface {
+	// Observe records the handler latency and response status of a request.
+	Observe(latency time.Duration, status int)
+}
+
+// ClientState is a point-in-time view of a single client's limiter state.
+// Only the fields relevant to the reporting strategy are populated.
+type ClientState struct {
+	ClientId string
+	// Limit is the configured capacity (Limit or BucketSize).
+	Limit float64
+	// Remaining is how many requests would be admitted right now.
+	Remaining float64
+	// Tokens is the token bucket fill level.
+	Tokens float64
+	// Count is the number of requests in the current fixed or sliding window.
+	Count int
+	// Queued is the leaky bucket queue depth.
+	Queued float64
+	// WindowStart is the start of the current fixed window.
+	WindowStart time.Time
+	RetryAfter  time.Duration
+}
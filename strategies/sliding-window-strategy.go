@@ -1,6 +1,8 @@
 package strategies
 
 import (
+	"io"
+	"sort"
 	"sync"
 	"time"
 )
@@ -8,8 +10,10 @@ import (
 type SlidingWindowStrategy struct {
 	Limit      int
 	WindowSize time.Duration
-	clients    map[string][]time.Time
-	mutex      sync.Mutex
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string][]time.Time
+	mutex   sync.Mutex
 }
 
 // NewSlidingWindowStrategy creates a new Sliding Window rate limiting strategy.
@@ -33,23 +37,11 @@ func NewSlidingWindowStrategy(limit int, windowSize time.Duration) *SlidingWindo
 }
 
 func (strategy *SlidingWindowStrategy) IsRequestAllowed(clientId string) bool {
-	now := time.Now()
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
-	timestamps, exists := strategy.clients[clientId]
-	if !exists {
-		timestamps = []time.Time{}
-	}
-
-	// Filter out old timestamps
-	filtered := timestamps[:0]
-	for _, t := range timestamps {
-		if now.Sub(t) < strategy.WindowSize {
-			filtered = append(filtered, t)
-		}
-	}
-	timestamps = filtered
+	timestamps := strategy.timestamps(clientId, now)
 
 	if len(timestamps) < strategy.Limit {
 		timestamps = append(timestamps, now)
@@ -57,22 +49,141 @@ func (strategy *SlidingWindowStrategy) IsRequestAllowed(clientId string) bool {
 		return true
 	}
 
-	strategy.clients[clientId] = timestamps
 	return false
 }
 
 // RetryAfter returns how long until the oldest timestamp expires and capacity frees up.
 func (strategy *SlidingWindowStrategy) RetryAfter(clientId string) time.Duration {
-	now := time.Now()
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
-	timestamps := strategy.clients[clientId]
-	if len(timestamps) == 0 {
+	if len(strategy.clients[clientId]) == 0 {
 		return 0
 	}
 
-	// refresh timestamps to drop stale entries
+	return strategy.retryAfter(strategy.timestamps(clientId, now), now)
+}
+
+// Clients returns the ids of all tracked clients, sorted.
+func (strategy *SlidingWindowStrategy) Clients() []string {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	ids := make([]string, 0, len(strategy.clients))
+	for id := range strategy.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Inspect reports the number of requests inside the client's sliding window.
+func (strategy *SlidingWindowStrategy) Inspect(clientId string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	result := ClientState{
+		ClientId:  clientId,
+		Limit:     float64(strategy.Limit),
+		Remaining: float64(strategy.Limit),
+	}
+
+	if _, exists := strategy.clients[clientId]; !exists {
+		return result, false
+	}
+
+	timestamps := strategy.timestamps(clientId, now)
+	result.Count = len(timestamps)
+	result.Remaining = float64(max(0, strategy.Limit-len(timestamps)))
+	result.RetryAfter = strategy.retryAfter(timestamps, now)
+	return result, true
+}
+
+// Reset forgets all of the client's request timestamps.
+func (strategy *SlidingWindowStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	delete(strategy.clients, clientId)
+}
+
+// Charge records n requests at the current time, up to Limit.
+func (strategy *SlidingWindowStrategy) Charge(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	timestamps := strategy.timestamps(clientId, now)
+	for i := 0; i < n && len(timestamps) < strategy.Limit; i++ {
+		timestamps = append(timestamps, now)
+	}
+	strategy.clients[clientId] = timestamps
+}
+
+// Refund removes the client's n most recent timestamps.
+func (strategy *SlidingWindowStrategy) Refund(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	if _, exists := strategy.clients[clientId]; !exists {
+		return
+	}
+	timestamps := strategy.timestamps(clientId, now)
+	strategy.clients[clientId] = timestamps[:len(timestamps)-min(len(timestamps), max(0, n))]
+}
+
+// Snapshot writes the request timestamps of all clients to w.
+func (strategy *SlidingWindowStrategy) Snapshot(w io.Writer) error {
+	strategy.mutex.Lock()
+	clients := make(map[string][]time.Time, len(strategy.clients))
+	for id, timestamps := range strategy.clients {
+		clients[id] = append([]time.Time(nil), timestamps...)
+	}
+	strategy.mutex.Unlock()
+
+	return writeSnapshot(w, "sliding-window", clockNow(strategy.Clock), clients)
+}
+
+// Restore replaces all timestamps with those read from r, dropping those that
+// have slid out of the window in the meantime.
+func (strategy *SlidingWindowStrategy) Restore(r io.Reader) error {
+	clients, err := readSnapshot[[]time.Time](r, "sliding-window")
+	if err != nil {
+		return err
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.clients = make(map[string][]time.Time, len(clients))
+	for id, timestamps := range clients {
+		var kept []time.Time
+		for _, t := range timestamps {
+			t = notAfter(t, now)
+			if now.Sub(t) < strategy.WindowSize {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) > 0 {
+			strategy.clients[id] = kept
+		}
+	}
+	return nil
+}
+
+// timestamps returns the client's timestamps with stale entries dropped.
+// The caller must hold the mutex.
+func (strategy *SlidingWindowStrategy) timestamps(clientId string, now time.Time) []time.Time {
+	timestamps, exists := strategy.clients[clientId]
+	if !exists {
+		timestamps = []time.Time{}
+	}
+
+	// Filter out old timestamps
 	filtered := timestamps[:0]
 	for _, t := range timestamps {
 		if now.Sub(t) < strategy.WindowSize {
@@ -80,12 +191,16 @@ func (strategy *SlidingWindowStrategy) RetryAfter(clientId string) time.Duration
 		}
 	}
 	strategy.clients[clientId] = filtered
+	return filtered
+}
 
-	if len(filtered) < strategy.Limit {
+// retryAfter must be called with the mutex held.
+func (strategy *SlidingWindowStrategy) retryAfter(timestamps []time.Time, now time.Time) time.Duration {
+	if len(timestamps) == 0 || len(timestamps) < strategy.Limit {
 		return 0
 	}
 
-	oldest := filtered[0]
+	oldest := timestamps[0]
 	wait := oldest.Add(strategy.WindowSize).Sub(now)
 	if wait < 0 {
 		return 0
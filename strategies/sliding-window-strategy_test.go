@@ -158,3 +158,55 @@ func TestRetryAfter_SlidingWindow(t *testing.T) {
 		t.Fatal("expected zero retry-after after window slide")
 	}
 }
+
+// Inspect, Charge and Reset should observe and manipulate the window timestamps.
+func TestInspectChargeReset_SlidingWindow(t *testing.T) {
+	s := NewSlidingWindowStrategy(3, time.Minute)
+	client := "userA"
+
+	s.Charge(client, 2)
+	state, tracked := s.Inspect(client)
+	if !tracked || state.Count != 2 || state.Remaining != 1 || state.RetryAfter != 0 {
+		t.Fatalf("after charge: unexpected state %+v", state)
+	}
+
+	s.Charge(client, 10)
+	if state, _ := s.Inspect(client); state.Count != 3 {
+		t.Fatalf("charge should stop at limit, got count %d", state.Count)
+	}
+
+	s.Reset(client)
+	if !s.IsRequestAllowed(client) {
+		t.Fatal("expected allowed after reset")
+	}
+}
+
+// Refund should drop the most recent timestamps and never more than exist.
+func TestRefund_SlidingWindow(t *testing.T) {
+	limit := 50
+	s := NewSlidingWindowStrategy(limit, time.Minute)
+	client := "userA"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.IsRequestAllowed(client) {
+				s.Refund(client, 1)
+			}
+			s.Refund(client, 1)
+		}()
+	}
+	wg.Wait()
+
+	if state, _ := s.Inspect(client); state.Count != 0 {
+		t.Fatalf("expected empty window, got %+v", state)
+	}
+
+	s.Charge(client, 3)
+	s.Refund(client, 2)
+	if state, _ := s.Inspect(client); state.Count != 1 {
+		t.Fatalf("expected one timestamp left, got %d", state.Count)
+	}
+}
@@ -0,0 +1,64 @@
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format written by Snapshot.
+const SnapshotVersion = 1
+
+// Snapshotter is implemented by strategies whose client state can be saved,
+// e.g. on shutdown, and restored on start.
+type Snapshotter interface {
+	// Snapshot writes the state of all clients to w.
+	Snapshot(w io.Writer) error
+	// Restore replaces the state of all clients with a snapshot read from r.
+	// Timestamps are re-based against the current time, so state that expired
+	// while the snapshot was stored is discarded.
+	Restore(r io.Reader) error
+}
+
+// snapshot is the versioned JSON envelope shared by all strategies.
+type snapshot[T any] struct {
+	Version  int          `json:"version"`
+	Strategy string       `json:"strategy"`
+	TakenAt  time.Time    `json:"takenAt"`
+	Clients  map[string]T `json:"clients"`
+}
+
+func writeSnapshot[T any](w io.Writer, strategy string, takenAt time.Time, clients map[string]T) error {
+	return json.NewEncoder(w).Encode(snapshot[T]{
+		Version:  SnapshotVersion,
+		Strategy: strategy,
+		TakenAt:  takenAt,
+		Clients:  clients,
+	})
+}
+
+func readSnapshot[T any](r io.Reader, strategy string) (map[string]T, error) {
+	var decoded snapshot[T]
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	if decoded.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", decoded.Version)
+	}
+	if decoded.Strategy != strategy {
+		return nil, fmt.Errorf("snapshot of %q cannot be restored into %q", decoded.Strategy, strategy)
+	}
+	if decoded.Clients == nil {
+		decoded.Clients = make(map[string]T)
+	}
+	return decoded.Clients, nil
+}
+
+// notAfter clamps timestamps written by a host whose clock ran ahead.
+func notAfter(t, now time.Time) time.Time {
+	if t.After(now) {
+		return now
+	}
+	return t
+}
@@ -0,0 +1,116 @@
+package strategies
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A restored strategy should continue to deny clients that were limited before.
+func TestSnapshotRoundTrip(t *testing.T) {
+	cases := []struct {
+		name    string
+		fill    func() Snapshotter
+		restore func() RateLimitStrategy
+	}{
+		{"fixed-window", func() Snapshotter {
+			s := NewFixedWindowStrategy(1, time.Minute)
+			s.IsRequestAllowed("userA")
+			return s
+		}, func() RateLimitStrategy { return NewFixedWindowStrategy(1, time.Minute) }},
+		{"sliding-window", func() Snapshotter {
+			s := NewSlidingWindowStrategy(1, time.Minute)
+			s.IsRequestAllowed("userA")
+			return s
+		}, func() RateLimitStrategy { return NewSlidingWindowStrategy(1, time.Minute) }},
+		{"token-bucket", func() Snapshotter {
+			s := NewTokenBucketStrategy(0.01, 1)
+			s.IsRequestAllowed("userA")
+			return s
+		}, func() RateLimitStrategy { return NewTokenBucketStrategy(0.01, 1) }},
+		{"leaky-bucket", func() Snapshotter {
+			s := NewLeakyBucketStrategy(0, 1)
+			s.IsRequestAllowed("userA")
+			return s
+		}, func() RateLimitStrategy { return NewLeakyBucketStrategy(0, 1) }},
+	}
+
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		if err := tc.fill().Snapshot(&buf); err != nil {
+			t.Fatalf("%s: snapshot failed: %v", tc.name, err)
+		}
+
+		restored := tc.restore()
+		if err := restored.(Snapshotter).Restore(&buf); err != nil {
+			t.Fatalf("%s: restore failed: %v", tc.name, err)
+		}
+		if restored.IsRequestAllowed("userA") {
+			t.Errorf("%s: restored client should still be limited", tc.name)
+		}
+		if !restored.IsRequestAllowed("userB") {
+			t.Errorf("%s: unknown client should be allowed", tc.name)
+		}
+	}
+}
+
+// Windows that ended while the snapshot was stored must not be resurrected.
+func TestRestoreDropsExpiredState(t *testing.T) {
+	old := time.Now().Add(-2 * time.Minute).Format(time.RFC3339Nano)
+
+	fixed := NewFixedWindowStrategy(1, time.Minute)
+	data := fmt.Sprintf(`{"version":1,"strategy":"fixed-window","clients":{"userA":{"windowStart":%q,"requestCount":1}}}`, old)
+	if err := fixed.Restore(strings.NewReader(data)); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if len(fixed.Clients()) != 0 {
+		t.Fatalf("expired fixed window was restored: %v", fixed.Clients())
+	}
+
+	sliding := NewSlidingWindowStrategy(1, time.Minute)
+	data = fmt.Sprintf(`{"version":1,"strategy":"sliding-window","clients":{"userA":[%q]}}`, old)
+	if err := sliding.Restore(strings.NewReader(data)); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !sliding.IsRequestAllowed("userA") {
+		t.Fatal("expired sliding window timestamp was restored")
+	}
+
+	bucket := NewTokenBucketStrategy(1, 10)
+	data = fmt.Sprintf(`{"version":1,"strategy":"token-bucket","clients":{"userA":{"tokens":0,"lastRefill":%q}}}`, old)
+	if err := bucket.Restore(strings.NewReader(data)); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if len(bucket.Clients()) != 0 {
+		t.Fatal("bucket refilled during downtime should be dropped")
+	}
+}
+
+// Timestamps from the future are clamped so clients are not locked out by clock skew.
+func TestRestoreClampsFutureTimestamps(t *testing.T) {
+	future := time.Now().Add(time.Hour).Format(time.RFC3339Nano)
+
+	fixed := NewFixedWindowStrategy(1, time.Minute)
+	data := fmt.Sprintf(`{"version":1,"strategy":"fixed-window","clients":{"userA":{"windowStart":%q,"requestCount":1}}}`, future)
+	if err := fixed.Restore(strings.NewReader(data)); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if wait := fixed.RetryAfter("userA"); wait > time.Minute {
+		t.Fatalf("expected retry-after within one window, got %v", wait)
+	}
+}
+
+func TestRestoreRejectsMismatchedSnapshots(t *testing.T) {
+	s := NewTokenBucketStrategy(1, 1)
+	if err := s.Restore(strings.NewReader(`{"version":99,"strategy":"token-bucket"}`)); err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+	if err := s.Restore(strings.NewReader(`{"version":1,"strategy":"fixed-window"}`)); err == nil {
+		t.Fatal("expected error for snapshot of another strategy")
+	}
+	if err := s.Restore(strings.NewReader(`not json`)); err == nil {
+		t.Fatal("expected error for malformed snapshot")
+	}
+}
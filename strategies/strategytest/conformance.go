@@ -0,0 +1,170 @@
+package strategytest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gabisonia/fiber-rate-limiter/strategies"
+)
+
+// Config describes a strategy under test and the guarantees it must keep.
+type Config struct {
+	// New creates the strategy driven by clock.
+	New func(clock strategies.Clock) strategies.RateLimitStrategy
+	// Window and MaxPerWindow bound admissions: no client may be admitted more
+	// than MaxPerWindow times in any half-open interval of length Window. For
+	// a fixed window of limit n that is 2n; for a token bucket it is
+	// floor(BucketSize + RefillRate*Window).
+	Window       time.Duration
+	MaxPerWindow int
+	// Clients is the number of distinct clients in generated schedules.
+	// Defaults to 3.
+	Clients int
+	// Steps is the length of generated schedules. Defaults to 500.
+	Steps int
+	// MaxGap bounds the delay between generated requests. Defaults to Window/4.
+	MaxGap time.Duration
+}
+
+// Step is a single request in a schedule.
+type Step struct {
+	// Delay is how far the clock advances before the request.
+	Delay  time.Duration
+	Client int
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Clients <= 0 {
+		cfg.Clients = 3
+	}
+	if cfg.Steps <= 0 {
+		cfg.Steps = 500
+	}
+	if cfg.MaxGap <= 0 {
+		cfg.MaxGap = max(cfg.Window/4, time.Nanosecond)
+	}
+	return cfg
+}
+
+// RandomSchedule generates a schedule mixing bursts of simultaneous requests
+// with idle gaps.
+func RandomSchedule(rng *rand.Rand, cfg Config) []Step {
+	cfg = cfg.withDefaults()
+	schedule := make([]Step, cfg.Steps)
+	for i := range schedule {
+		schedule[i].Client = rng.Intn(cfg.Clients)
+		if rng.Intn(3) > 0 { // a third of requests arrive in bursts
+			schedule[i].Delay = time.Duration(rng.Int63n(int64(cfg.MaxGap) + 1))
+		}
+	}
+	return schedule
+}
+
+// ScheduleFromBytes decodes a schedule from fuzzer input, two bytes per step:
+// the client and the delay as a fraction of MaxGap.
+func ScheduleFromBytes(data []byte, cfg Config) []Step {
+	cfg = cfg.withDefaults()
+	schedule := make([]Step, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		schedule = append(schedule, Step{
+			Client: int(data[i]) % cfg.Clients,
+			Delay:  cfg.MaxGap * time.Duration(data[i+1]) / 255,
+		})
+	}
+	return schedule
+}
+
+// Run checks the strategy against several seeded random schedules, each as a
+// subtest.
+func Run(t *testing.T, cfg Config) {
+	t.Helper()
+	for seed := int64(1); seed <= 20; seed++ {
+		schedule := RandomSchedule(rand.New(rand.NewSource(seed)), cfg)
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			Check(t, cfg, schedule)
+		})
+	}
+}
+
+// Check drives a fresh strategy through schedule on a FakeClock and reports
+// any broken invariant:
+//   - no client is admitted more than MaxPerWindow times in any Window;
+//   - RetryAfter is never negative, and a client that waits for it is admitted;
+//   - strategies implementing strategies.Inspector never report negative or
+//     over-capacity state.
+func Check(t testing.TB, cfg Config, schedule []Step) {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	clock := NewFakeClock(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))
+	strategy := cfg.New(clock)
+	inspector, _ := strategy.(strategies.Inspector)
+
+	admitted := make(map[string][]time.Time)
+	retryAt := make(map[string]time.Time)
+
+	for i, step := range schedule {
+		clock.Advance(step.Delay)
+		now := clock.Now()
+		client := fmt.Sprintf("client-%d", step.Client)
+
+		allowed := strategy.IsRequestAllowed(client)
+		if due, waiting := retryAt[client]; waiting && !now.Before(due) && !allowed {
+			t.Fatalf("step %d: %s denied at %v although told to retry at %v", i, client, now, due)
+		}
+		delete(retryAt, client)
+
+		if allowed {
+			admitted[client] = append(admitted[client], now)
+		} else {
+			wait := strategy.RetryAfter(client)
+			if wait < 0 {
+				t.Fatalf("step %d: negative retry-after %v for %s", i, wait, client)
+			}
+			if wait > 0 {
+				retryAt[client] = now.Add(wait)
+			}
+		}
+
+		if inspector != nil {
+			checkState(t, i, inspector, client)
+		}
+	}
+
+	for client, times := range admitted {
+		if count, start := busiestWindow(times, cfg.Window); count > cfg.MaxPerWindow {
+			t.Fatalf("%s admitted %d times in the %v from %v, limit %d", client, count, cfg.Window, start, cfg.MaxPerWindow)
+		}
+	}
+}
+
+func checkState(t testing.TB, step int, inspector strategies.Inspector, client string) {
+	t.Helper()
+	state, _ := inspector.Inspect(client)
+	if state.Remaining < 0 || state.Tokens < 0 || state.Queued < 0 || state.Count < 0 || state.RetryAfter < 0 {
+		t.Fatalf("step %d: negative state for %s: %+v", step, client, state)
+	}
+	if state.Limit > 0 && (state.Remaining > state.Limit || state.Tokens > state.Limit || float64(state.Count) > state.Limit) {
+		t.Fatalf("step %d: state for %s exceeds capacity: %+v", step, client, state)
+	}
+}
+
+// busiestWindow returns the largest number of times falling in any half-open
+// interval of length window, and where that interval starts.
+func busiestWindow(times []time.Time, window time.Duration) (int, time.Time) {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	best, bestStart := 0, time.Time{}
+	start := 0
+	for end := range times {
+		for times[end].Sub(times[start]) >= window {
+			start++
+		}
+		if count := end - start + 1; count > best {
+			best, bestStart = count, times[start]
+		}
+	}
+	return best, bestStart
+}
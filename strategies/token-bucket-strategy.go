@@ -1,7 +1,9 @@
 package strategies
 
 import (
+	"io"
 	"math"
+	"sort"
 	"sync"
 	"time"
 )
@@ -9,13 +11,15 @@ import (
 type TokenBucketStrategy struct {
 	RefillRate float64
 	BucketSize float64
-	clients    map[string]*tokenBucketState
-	mutex      sync.Mutex
+	// Clock, if set, replaces the system clock.
+	Clock   Clock
+	clients map[string]*tokenBucketState
+	mutex   sync.Mutex
 }
 
 type tokenBucketState struct {
-	Tokens     float64
-	LastRefill time.Time
+	Tokens     float64   `json:"tokens"`
+	LastRefill time.Time `json:"lastRefill"`
 }
 
 // NewTokenBucketStrategy creates a new Token Bucket rate limiting strategy.
@@ -39,22 +43,20 @@ func NewTokenBucketStrategy(refillRate, bucketSize float64) *TokenBucketStrategy
 }
 
 func (strategy *TokenBucketStrategy) IsRequestAllowed(clientId string) bool {
-	now := time.Now()
+	return strategy.IsRequestAllowedN(clientId, 1)
+}
+
+// IsRequestAllowedN consumes n tokens if the bucket holds at least n, e.g. to
+// charge a body's size against a bucket denominated in bytes.
+func (strategy *TokenBucketStrategy) IsRequestAllowedN(clientId string, n int) bool {
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
-	state, exists := strategy.clients[clientId]
-	if !exists {
-		state = &tokenBucketState{Tokens: strategy.BucketSize, LastRefill: now}
-		strategy.clients[clientId] = state
-	}
-
-	elapsed := now.Sub(state.LastRefill).Seconds()
-	state.Tokens = math.Min(strategy.BucketSize, state.Tokens+(elapsed*strategy.RefillRate))
-	state.LastRefill = now
+	state := strategy.state(clientId, now)
 
-	if state.Tokens >= 1 {
-		state.Tokens--
+	if state.Tokens >= float64(n) {
+		state.Tokens -= float64(max(0, n))
 		return true
 	}
 
@@ -63,27 +65,201 @@ func (strategy *TokenBucketStrategy) IsRequestAllowed(clientId string) bool {
 
 // RetryAfter returns how long until at least one token is available.
 func (strategy *TokenBucketStrategy) RetryAfter(clientId string) time.Duration {
-	now := time.Now()
+	return strategy.RetryAfterN(clientId, 1)
+}
+
+// RetryAfterN returns how long until n tokens are available, or zero if n
+// exceeds BucketSize and never will be.
+func (strategy *TokenBucketStrategy) RetryAfterN(clientId string, n int) time.Duration {
+	now := clockNow(strategy.Clock)
 	strategy.mutex.Lock()
 	defer strategy.mutex.Unlock()
 
 	state, exists := strategy.clients[clientId]
-	if !exists {
+	if !exists || float64(n) > strategy.BucketSize {
 		return 0
 	}
 
+	strategy.refill(state, now)
+	return strategy.retryAfterN(state, float64(n))
+}
+
+// Clients returns the ids of all tracked clients, sorted.
+func (strategy *TokenBucketStrategy) Clients() []string {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	ids := make([]string, 0, len(strategy.clients))
+	for id := range strategy.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Inspect reports the number of tokens currently in the client's bucket.
+func (strategy *TokenBucketStrategy) Inspect(clientId string) (ClientState, bool) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	result := ClientState{
+		ClientId:  clientId,
+		Limit:     strategy.BucketSize,
+		Tokens:    strategy.BucketSize,
+		Remaining: math.Floor(strategy.BucketSize),
+	}
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		return result, false
+	}
+
+	strategy.refill(state, now)
+	result.Tokens = state.Tokens
+	result.Remaining = math.Max(0, math.Floor(state.Tokens))
+	result.RetryAfter = strategy.retryAfter(state)
+	return result, true
+}
+
+// Reset forgets the client's bucket so the next request starts full.
+func (strategy *TokenBucketStrategy) Reset(clientId string) {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	delete(strategy.clients, clientId)
+}
+
+// Charge removes n tokens from the client's bucket, down to empty.
+func (strategy *TokenBucketStrategy) Charge(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state := strategy.state(clientId, now)
+	state.Tokens = math.Max(0, state.Tokens-float64(max(0, n)))
+}
+
+// Debit removes n tokens from the client's bucket, going below empty if
+// needed, e.g. to charge a response body only known after it was produced.
+// The bucket admits nothing until it has refilled past zero.
+func (strategy *TokenBucketStrategy) Debit(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state := strategy.state(clientId, now)
+	state.Tokens -= float64(max(0, n))
+}
+
+// Refund puts n tokens back into the client's bucket, up to BucketSize.
+func (strategy *TokenBucketStrategy) Refund(clientId string, n int) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		return
+	}
+	strategy.refill(state, now)
+	state.Tokens = math.Min(strategy.BucketSize, state.Tokens+float64(max(0, n)))
+}
+
+// SetRefillRate changes RefillRate while the strategy is in use. Buckets are
+// first refilled at the old rate up to now, so the change only applies from
+// now on. Negative rates are treated as zero.
+func (strategy *TokenBucketStrategy) SetRefillRate(refillRate float64) {
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	for _, state := range strategy.clients {
+		strategy.refill(state, now)
+	}
+	strategy.RefillRate = math.Max(0, refillRate)
+}
+
+// CurrentRefillRate returns RefillRate; unlike reading the field, it is safe
+// to call while SetRefillRate may run concurrently.
+func (strategy *TokenBucketStrategy) CurrentRefillRate() float64 {
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	return strategy.RefillRate
+}
+
+// Snapshot writes the buckets of all clients to w.
+func (strategy *TokenBucketStrategy) Snapshot(w io.Writer) error {
+	strategy.mutex.Lock()
+	clients := make(map[string]tokenBucketState, len(strategy.clients))
+	for id, state := range strategy.clients {
+		clients[id] = *state
+	}
+	strategy.mutex.Unlock()
+
+	return writeSnapshot(w, "token-bucket", clockNow(strategy.Clock), clients)
+}
+
+// Restore replaces all buckets with those read from r. Buckets keep refilling
+// for the time the snapshot was stored; those that are full by now are dropped.
+func (strategy *TokenBucketStrategy) Restore(r io.Reader) error {
+	clients, err := readSnapshot[tokenBucketState](r, "token-bucket")
+	if err != nil {
+		return err
+	}
+
+	now := clockNow(strategy.Clock)
+	strategy.mutex.Lock()
+	defer strategy.mutex.Unlock()
+
+	strategy.clients = make(map[string]*tokenBucketState, len(clients))
+	for id, state := range clients {
+		state.LastRefill = notAfter(state.LastRefill, now)
+		strategy.refill(&state, now)
+		if state.Tokens >= strategy.BucketSize {
+			continue
+		}
+		strategy.clients[id] = &state
+	}
+	return nil
+}
+
+// state returns the client's refilled bucket, creating a full one if needed.
+// The caller must hold the mutex.
+func (strategy *TokenBucketStrategy) state(clientId string, now time.Time) *tokenBucketState {
+	state, exists := strategy.clients[clientId]
+	if !exists {
+		state = &tokenBucketState{Tokens: strategy.BucketSize, LastRefill: now}
+		strategy.clients[clientId] = state
+	}
+
+	strategy.refill(state, now)
+	return state
+}
+
+// refill must be called with the mutex held.
+func (strategy *TokenBucketStrategy) refill(state *tokenBucketState, now time.Time) {
 	elapsed := now.Sub(state.LastRefill).Seconds()
 	state.Tokens = math.Min(strategy.BucketSize, state.Tokens+(elapsed*strategy.RefillRate))
 	state.LastRefill = now
+}
+
+// retryAfter must be called with the mutex held.
+func (strategy *TokenBucketStrategy) retryAfter(state *tokenBucketState) time.Duration {
+	return strategy.retryAfterN(state, 1)
+}
 
-	if state.Tokens >= 1 {
+// retryAfterN must be called with the mutex held.
+func (strategy *TokenBucketStrategy) retryAfterN(state *tokenBucketState, n float64) time.Duration {
+	if state.Tokens >= n {
 		return 0
 	}
 	if strategy.RefillRate <= 0 {
 		return 0
 	}
 
-	needed := 1 - state.Tokens
+	needed := n - state.Tokens
 	seconds := needed / strategy.RefillRate
 	if seconds < 0 {
 		return 0
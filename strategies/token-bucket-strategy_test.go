@@ -163,3 +163,97 @@ func TestRetryAfter_TokenBucket(t *testing.T) {
 		t.Fatal("expected zero retry-after after refill")
 	}
 }
+
+// Inspect, Charge and Reset should observe and manipulate the bucket level.
+func TestInspectChargeReset_TokenBucket(t *testing.T) {
+	s := NewTokenBucketStrategy(0, 5)
+	client := "userA"
+
+	s.Charge(client, 2)
+	state, tracked := s.Inspect(client)
+	if !tracked || state.Tokens != 3 || state.Remaining != 3 {
+		t.Fatalf("after charge: unexpected state %+v", state)
+	}
+
+	s.Charge(client, 10)
+	if state, _ := s.Inspect(client); state.Tokens != 0 {
+		t.Fatalf("charge should not go below empty, got %v tokens", state.Tokens)
+	}
+
+	s.Reset(client)
+	if state, tracked := s.Inspect(client); tracked || state.Tokens != 5 {
+		t.Fatalf("after reset: unexpected state %+v", state)
+	}
+}
+
+// Concurrent refunds should restore tokens without overfilling the bucket.
+func TestRefund_TokenBucket(t *testing.T) {
+	bucketSize := 50.0
+	s := NewTokenBucketStrategy(0, bucketSize)
+	client := "userA"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.IsRequestAllowed(client) {
+				s.Refund(client, 1)
+			}
+			s.Refund(client, 1) // extra refunds must not exceed capacity
+		}()
+	}
+	wg.Wait()
+
+	if state, _ := s.Inspect(client); state.Tokens != bucketSize {
+		t.Fatalf("expected full bucket of %v, got %v", bucketSize, state.Tokens)
+	}
+	s.Refund("unknown", 1)
+	if len(s.Clients()) != 1 {
+		t.Fatal("refunding an untracked client should not track it")
+	}
+}
+
+// Weighted requests consume n tokens at once, and never fit if n exceeds the bucket.
+func TestIsRequestAllowedN_TokenBucket(t *testing.T) {
+	tb := NewTokenBucketStrategy(0, 100)
+
+	if !tb.IsRequestAllowedN("u", 60) {
+		t.Fatal("expected 60 of 100 tokens to be admitted")
+	}
+	if tb.IsRequestAllowedN("u", 60) {
+		t.Fatal("expected 60 more tokens to be denied with 40 left")
+	}
+	if !tb.IsRequestAllowedN("u", 40) {
+		t.Fatal("expected the remaining 40 tokens to be admitted")
+	}
+	if tb.IsRequestAllowedN("v", 101) {
+		t.Fatal("expected a request larger than the bucket to be denied")
+	}
+	if wait := tb.RetryAfterN("v", 101); wait != 0 {
+		t.Fatalf("expected no retry-after for a request that never fits, got %s", wait)
+	}
+
+	tb.SetRefillRate(10)
+	if wait := tb.RetryAfterN("u", 20); wait != 2*time.Second {
+		t.Fatalf("expected 2s until 20 tokens refill, got %s", wait)
+	}
+}
+
+// Debit can take the bucket below empty; nothing is admitted until it refills past the debt.
+func TestDebit_TokenBucket(t *testing.T) {
+	tb := NewTokenBucketStrategy(0, 100)
+
+	tb.Debit("u", 250)
+	if state, _ := tb.Inspect("u"); state.Tokens != -150 || state.Remaining != 0 {
+		t.Fatalf("expected a debt of 150 tokens and nothing remaining, got %+v", state)
+	}
+	if tb.IsRequestAllowed("u") {
+		t.Fatal("expected a client in debt to be denied")
+	}
+
+	tb.SetRefillRate(100)
+	if wait := tb.RetryAfter("u"); wait != 1510*time.Millisecond {
+		t.Fatalf("expected 1.51s until the debt is paid off and a token refills, got %s", wait)
+	}
+}